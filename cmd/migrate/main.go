@@ -2,65 +2,113 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/sqlite"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/parsel-email/lib-go/migrations"
 	"github.com/tursodatabase/libsql-client-go/libsql"
+	_ "modernc.org/sqlite"
 )
 
-const migrationsDir = "./db/migrations"
+const (
+	migrationsDir = "./db/migrations"
+	seedsDir      = "./db/seeds"
+)
+
+var (
+	dirFlag    = flag.String("dir", "", "Migrations directory (default $MIGRATIONS_DIR or "+migrationsDir+")")
+	sourceFlag = flag.String("source", "", "Migrations to apply instead of -dir: a file://, iofs:// or https:// URL")
+	driverFlag = flag.String("driver", "libsql", "Database connection, libsql or sqlite; migrations use the SQLite dialect with either")
+	jsonFlag   = flag.Bool("json", false, "Print version and status as JSON")
+)
+
+// migrationState is the output of the version and status commands
+type migrationState struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+	Pending *int `json:"pending,omitempty"` // Only reported by status
+}
 
 func main() {
 	flag.Parse()
 
 	args := flag.Args()
 	if len(args) < 1 {
-		log.Fatal("Command is required: new, up, down, version")
+		log.Fatal("Command is required: new, up, down, redo, drop, seed, version, status, verify, validate, dump-schema")
 	}
 
 	cmd := args[0]
 
 	switch cmd {
 	case "new":
-		if len(args) != 2 {
-			log.Fatal("Migration name is required")
-		}
-		createMigration(args[1])
+		createMigration(args[1:])
 	case "up":
-		runMigration(func(m *migrate.Migrate) error {
-			return m.Up()
-		})
+		runMigration("up", migrations.Up)
 	case "down":
-		runMigration(func(m *migrate.Migrate) error {
-			return m.Down()
-		})
+		runMigration("down", migrations.Down)
+	case "redo":
+		redoMigration()
+	case "drop":
+		dropDatabase(args[1:])
+	case "seed":
+		seedDatabase(args[1:])
 	case "version":
 		getMigrationVersion()
+	case "status":
+		getMigrationStatus()
+	case "verify":
+		verifyMigrations()
+	case "validate":
+		validateMigrations()
+	case "dump-schema":
+		dumpSchema(args[1:])
 	default:
 		log.Fatalf("Unknown command: %s", cmd)
 	}
 }
 
-func createMigration(name string) {
-	timestamp := time.Now().Unix()
-	upMigration := filepath.Join(migrationsDir, fmt.Sprintf("%d_%s.up.sql", timestamp, name))
-	downMigration := filepath.Join(migrationsDir, fmt.Sprintf("%d_%s.down.sql", timestamp, name))
+func createMigration(args []string) {
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	seq := flags.Bool("seq", false, "Number the migration sequentially instead of by timestamp")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("Migration name is required")
+	}
+	name := flags.Arg(0)
+
+	dir := getMigrationsDir()
 
 	// Ensure migrations directory exists
-	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		log.Fatalf("Failed to create migrations directory: %v", err)
 	}
 
+	version := strconv.FormatInt(time.Now().Unix(), 10)
+	if *seq {
+		next, err := migrations.NextSequence(os.DirFS(dir))
+		if err != nil {
+			log.Fatalf("Failed to number migration: %v", err)
+		}
+		version = fmt.Sprintf("%04d", next)
+	}
+
+	upMigration := filepath.Join(dir, fmt.Sprintf("%s_%s.up.sql", version, name))
+	downMigration := filepath.Join(dir, fmt.Sprintf("%s_%s.down.sql", version, name))
+
 	// Create up migration file
 	if err := os.WriteFile(upMigration, []byte("-- Migration Up\n"), 0644); err != nil {
 		log.Fatalf("Failed to create up migration file: %v", err)
@@ -74,6 +122,35 @@ func createMigration(name string) {
 	fmt.Printf("Created migration files:\n%s\n%s\n", upMigration, downMigration)
 }
 
+// getMigrationsDir returns the directory new migrations are written to and,
+// unless -source is given, read from
+func getMigrationsDir() string {
+	if *dirFlag != "" {
+		return *dirFlag
+	}
+	if dir := os.Getenv("MIGRATIONS_DIR"); dir != "" {
+		return dir
+	}
+	return migrationsDir
+}
+
+// getSource returns where migrations are read from
+func getSource() string {
+	if *sourceFlag != "" {
+		return *sourceFlag
+	}
+	return getMigrationsDir()
+}
+
+// migrationSource opens the migrations selected with -source or -dir
+func migrationSource() fs.FS {
+	source, err := openSource(context.Background(), getSource(), http.DefaultClient)
+	if err != nil {
+		log.Fatalf("Failed to open migration source: %v", err)
+	}
+	return source
+}
+
 func getDBPath() string {
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
@@ -82,94 +159,257 @@ func getDBPath() string {
 	return dbPath
 }
 
-func runMigration(migrateFn func(*migrate.Migrate) error) {
-	dbPath := getDBPath()
+func runMigration(name string, migrateFn func(context.Context, *sql.DB, fs.FS) (uint, error)) {
+	db := openDB(getDBPath())
+	defer db.Close()
 
-	// Connect to database
-	db, err := sqlite.WithInstance(openDB(dbPath), &sqlite.Config{})
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	ctx := context.Background()
+	before, _, err := migrations.Version(ctx, db)
+	if err != nil && !errors.Is(err, migrations.ErrNoVersion) {
+		log.Fatalf("Failed to get migration version: %v", err)
 	}
 
-	// Initialize migrate instance
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsDir),
-		"sqlite",
-		db,
-	)
+	// Run migration function
+	after, err := migrateFn(ctx, db, migrationSource())
 	if err != nil {
-		log.Fatalf("Failed to create migration instance: %v", err)
+		log.Fatalf("Migration %s failed: %v", name, err)
 	}
 
-	// Run migration function
-	if err := migrateFn(m); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			fmt.Println("No migration needed")
-			return
-		}
-		log.Fatalf("Migration failed: %v", err)
+	if before == after {
+		fmt.Println("No migration needed")
+		return
 	}
 
 	fmt.Println("Migration successful")
 }
 
-func getMigrationVersion() {
-	dbPath := getDBPath()
+func redoMigration() {
+	db := openDB(getDBPath())
+	defer db.Close()
 
-	// Connect to database
-	db, err := sqlite.WithInstance(openDB(dbPath), &sqlite.Config{})
+	ctx := context.Background()
+	before, _, err := migrations.Version(ctx, db)
+	if errors.Is(err, migrations.ErrNoVersion) {
+		fmt.Println("No migration applied, nothing to redo")
+		return
+	}
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Failed to get migration version: %v", err)
 	}
 
-	// Initialize migrate instance
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsDir),
-		"sqlite",
-		db,
-	)
+	after, err := migrations.Redo(ctx, db, migrationSource())
 	if err != nil {
-		log.Fatalf("Failed to create migration instance: %v", err)
+		log.Fatalf("Redo failed: %v", err)
 	}
 
-	version, dirty, err := m.Version()
-	if err != nil {
-		if errors.Is(err, migrate.ErrNilVersion) {
-			fmt.Println("No migrations applied yet")
+	fmt.Printf("Redid migration: version %d -> %d\n", before, after)
+}
+
+func dropDatabase(args []string) {
+	flags := flag.NewFlagSet("drop", flag.ExitOnError)
+	confirm := flags.Bool("confirm", false, "Drop without the interactive prompt")
+	force := flags.Bool("force", false, "Allow dropping a remote libSQL database")
+	flags.Parse(args)
+
+	dbPath := getDBPath()
+	if isURL(dbPath) && !strings.HasPrefix(dbPath, "file:") && !*force {
+		log.Fatalf("Refusing to drop remote database %s without -force", dbPath)
+	}
+
+	if !*confirm {
+		fmt.Printf("This will drop all tables in %s. Type 'yes' to continue: ", dbPath)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Println("Aborted")
 			return
 		}
+	}
+
+	db := openDB(dbPath)
+	defer db.Close()
+
+	dropped, err := migrations.Drop(context.Background(), db)
+	if err != nil {
+		log.Fatalf("Drop failed: %v", err)
+	}
+
+	fmt.Printf("Dropped %d tables and views\n", dropped)
+}
+
+func seedDatabase(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	env := flags.String("env", "", "Seed subdirectory to run, e.g. dev")
+	flags.Parse(args)
+
+	dir := seedsDir
+	if *env != "" {
+		dir = filepath.Join(seedsDir, *env)
+	}
+
+	db := openDB(getDBPath())
+	defer db.Close()
+
+	n, err := migrations.Seed(context.Background(), db, os.DirFS(dir))
+	if err != nil {
+		log.Fatalf("Seeding failed: %v", err)
+	}
+
+	fmt.Printf("Ran %d seed files from %s\n", n, dir)
+}
+
+func getMigrationVersion() {
+	db := openDB(getDBPath())
+	defer db.Close()
+
+	printState(readState(context.Background(), db))
+}
+
+func getMigrationStatus() {
+	db := openDB(getDBPath())
+	defer db.Close()
+
+	ctx := context.Background()
+	state := readState(ctx, db)
+
+	pending, err := migrations.Pending(ctx, db, migrationSource())
+	if err != nil {
+		log.Fatalf("Failed to count pending migrations: %v", err)
+	}
+	state.Pending = &pending
+
+	printState(state)
+}
+
+// readState returns the current migration version, which is 0 if no
+// migration has been applied
+func readState(ctx context.Context, db *sql.DB) migrationState {
+	version, dirty, err := migrations.Version(ctx, db)
+	if err != nil && !errors.Is(err, migrations.ErrNoVersion) {
 		log.Fatalf("Failed to get migration version: %v", err)
 	}
+	return migrationState{Version: version, Dirty: dirty}
+}
 
-	fmt.Printf("Current migration version: %d (dirty: %v)\n", version, dirty)
+// printState prints state as JSON with -json, or as text otherwise
+func printState(state migrationState) {
+	if *jsonFlag {
+		if err := json.NewEncoder(os.Stdout).Encode(state); err != nil {
+			log.Fatalf("Failed to encode output: %v", err)
+		}
+		return
+	}
+
+	if state.Version == 0 {
+		fmt.Println("No migrations applied yet")
+	} else {
+		fmt.Printf("Current migration version: %d (dirty: %v)\n", state.Version, state.Dirty)
+	}
+	if state.Pending != nil {
+		fmt.Printf("Pending migrations: %d\n", *state.Pending)
+	}
 }
 
-func openDB(dbPath string) *sql.DB {
-	// Check if this is a libSQL URL or a local file
-	var db *sql.DB
-	var err error
+func verifyMigrations() {
+	db := openDB(getDBPath())
+	defer db.Close()
+
+	if err := migrations.Verify(context.Background(), db, migrationSource()); err != nil {
+		log.Fatalf("Verification failed: %v", err)
+	}
+
+	fmt.Println("All applied migrations match their recorded checksums")
+}
+
+func validateMigrations() {
+	issues, err := migrations.Validate(migrationSource())
+	if err != nil {
+		log.Fatalf("Validation failed: %v", err)
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	log.Fatalf("Found %d problems in %s", len(issues), getSource())
+}
 
-	if strings.HasPrefix(dbPath, "libsql://") {
-		// For remote libSQL URLs
+func dumpSchema(args []string) {
+	flags := flag.NewFlagSet("dump-schema", flag.ExitOnError)
+	out := flags.String("out", "", "File to write the schema to instead of stdout")
+	flags.Parse(args)
 
-		connOpts := []libsql.Option{}
+	db := openDB(getDBPath())
+	defer db.Close()
 
-		connector, err := libsql.NewConnector(dbPath, connOpts...)
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
 		if err != nil {
-			log.Fatalf("Failed to create libSQL connector: %v", err)
+			log.Fatalf("Failed to create %s: %v", *out, err)
 		}
-		db = sql.OpenDB(connector)
-	} else {
-		// For local files
-		connector, err := libsql.NewConnector(dbPath, nil)
+		defer f.Close()
+		w = f
+	}
+
+	if err := migrations.DumpSchema(context.Background(), db, w); err != nil {
+		log.Fatalf("Schema dump failed: %v", err)
+	}
+
+	if *out != "" {
+		if err := w.Close(); err != nil {
+			log.Fatalf("Failed to write %s: %v", *out, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote schema to %s\n", *out)
+	}
+}
+
+// getDriver returns the database/sql driver selected with -driver. It only
+// decides how the connection is opened: migrations are applied through the
+// SQLite migrate driver either way, which libSQL is compatible with.
+func getDriver() string {
+	switch *driverFlag {
+	case "sqlite", "libsql":
+		return *driverFlag
+	default:
+		log.Fatalf("Unknown driver %q: use sqlite or libsql", *driverFlag)
+		return ""
+	}
+}
+
+// isURL reports whether dbPath is a URL, remote or file:, rather than a
+// plain path or :memory:
+func isURL(dbPath string) bool {
+	return strings.Contains(dbPath, "://") || strings.HasPrefix(dbPath, "file:")
+}
+
+func openDB(dbPath string) *sql.DB {
+	if getDriver() == "sqlite" {
+		// SQLite files, opened with the driver the migrations use
+		db, err := sql.Open("sqlite", dbPath)
 		if err != nil {
-			log.Fatalf("Failed to create libSQL connector: %v", err)
+			log.Fatalf("Failed to open database: %v", err)
 		}
-		db = sql.OpenDB(connector)
+		// Keep an in-memory database on one connection
+		db.SetMaxOpenConns(1)
+		return db
 	}
 
+	// The libSQL client only takes URLs, so local paths become file: URLs
+	local := !isURL(dbPath)
+	if local {
+		dbPath = "file:" + dbPath
+	}
+	connector, err := libsql.NewConnector(dbPath)
 	if err != nil {
-		log.Fatalf("Failed to open database: %v", err)
+		log.Fatalf("Failed to create libSQL connector: %v", err)
+	}
+	db := sql.OpenDB(connector)
+	if local {
+		db.SetMaxOpenConns(1)
 	}
 	return db
 }
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parsel-email/lib-go/migrations"
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	// Run the command line instead of the tests when started by runCLI
+	if os.Getenv("MIGRATE_CLI_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// cliCommand returns the migrate command with args, run with the extra
+// environment variables in env
+func cliCommand(env []string, args ...string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(append(os.Environ(), "MIGRATE_CLI_MAIN=1"), env...)
+	return cmd
+}
+
+// runCLI runs the migrate command with args against the database at dbPath
+// and returns its standard output
+func runCLI(t *testing.T, dbPath string, args ...string) []byte {
+	t.Helper()
+
+	cmd := cliCommand([]string{"DB_PATH=" + dbPath}, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to run migrate %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out
+}
+
+// writeMigrations creates a directory holding a single migration
+func writeMigrations(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write migration: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestOpenSourceHTTPS(t *testing.T) {
+	server := httptest.NewTLSServer(http.StripPrefix("/migrations/", http.FileServer(http.Dir(writeMigrations(t)))))
+	defer server.Close()
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	source, err := openSource(ctx, server.URL+"/migrations", server.Client())
+	if err != nil {
+		t.Fatalf("Failed to open source: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	version, err := migrations.Up(ctx, db, source)
+	if err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Expected version 1, got %d", version)
+	}
+
+	if _, err := db.ExecContext(ctx, "INSERT INTO users (email) VALUES ('a@example.com')"); err != nil {
+		t.Errorf("Expected users table to exist, got: %v", err)
+	}
+}
+
+func TestOpenSourceSchemes(t *testing.T) {
+	dir := writeMigrations(t)
+	ctx := context.Background()
+
+	for _, source := range []string{dir, "file://" + dir, "iofs://" + dir} {
+		fsys, err := openSource(ctx, source, http.DefaultClient)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", source, err)
+		}
+		issues, err := migrations.Validate(fsys)
+		if err != nil {
+			t.Errorf("Failed to read %s: %v", source, err)
+		}
+		if len(issues) != 0 {
+			t.Errorf("Expected no problems in %s, got %v", source, issues)
+		}
+	}
+
+	for _, source := range []string{"http://example.com/migrations", "s3://bucket/migrations"} {
+		_, err := openSource(ctx, source, http.DefaultClient)
+		if err == nil || !strings.Contains(err.Error(), "unsupported migration source scheme") {
+			t.Errorf("Expected unsupported scheme error for %s, got: %v", source, err)
+		}
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	dir := writeMigrations(t)
+	dbPath := "file:" + filepath.Join(t.TempDir(), "cli.db")
+
+	var state migrationState
+	out := runCLI(t, dbPath, "-json", "-source", dir, "status")
+	if err := json.Unmarshal(out, &state); err != nil {
+		t.Fatalf("Failed to parse output %q: %v", out, err)
+	}
+	if state.Version != 0 || state.Pending == nil || *state.Pending != 1 {
+		t.Errorf("Expected version 0 with 1 pending, got %s", out)
+	}
+
+	runCLI(t, dbPath, "-source", dir, "up")
+
+	out = runCLI(t, dbPath, "-json", "version")
+	if string(bytes.TrimSpace(out)) != `{"version":1,"dirty":false}` {
+		t.Errorf("Expected version 1 as JSON, got %s", out)
+	}
+
+	state = migrationState{}
+	out = runCLI(t, dbPath, "-json", "-source", dir, "status")
+	if err := json.Unmarshal(out, &state); err != nil {
+		t.Fatalf("Failed to parse output %q: %v", out, err)
+	}
+	if state.Version != 1 || state.Dirty || state.Pending == nil || *state.Pending != 0 {
+		t.Errorf("Expected version 1 with nothing pending, got %s", out)
+	}
+
+	// Text stays the default
+	out = runCLI(t, dbPath, "version")
+	if !strings.Contains(string(out), "Current migration version: 1") {
+		t.Errorf("Expected text output, got %s", out)
+	}
+}
+
+func TestDirAndDriverFlags(t *testing.T) {
+	dir := writeMigrations(t)
+	dbPath := filepath.Join(t.TempDir(), "plain.db")
+
+	// A plain file path is opened through the libsql client by default
+	out := runCLI(t, dbPath, "-dir", dir, "up")
+	if !strings.Contains(string(out), "Migration successful") {
+		t.Errorf("Expected migration to run, got %s", out)
+	}
+	out = runCLI(t, dbPath, "-driver", "sqlite", "-json", "version")
+	if string(bytes.TrimSpace(out)) != `{"version":1,"dirty":false}` {
+		t.Errorf("Expected version 1, got %s", out)
+	}
+
+	// The same file as a file: URL, with either driver
+	for _, driver := range []string{"libsql", "sqlite"} {
+		out = runCLI(t, "file:"+dbPath, "-driver", driver, "-json", "version")
+		if string(bytes.TrimSpace(out)) != `{"version":1,"dirty":false}` {
+			t.Errorf("Expected version 1 through %s, got %s", driver, out)
+		}
+	}
+
+	// MIGRATIONS_DIR is used without -dir
+	cmd := cliCommand([]string{"DB_PATH=:memory:", "MIGRATIONS_DIR=" + dir}, "-driver", "sqlite", "up")
+	out, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "Migration successful") {
+		t.Errorf("Expected migration from MIGRATIONS_DIR to run, got %v: %s", err, out)
+	}
+
+	// A missing directory fails before touching the database
+	cmd = cliCommand([]string{"DB_PATH=:memory:"}, "-dir", filepath.Join(dir, "missing"), "up")
+	out, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "opening migrations directory") {
+		t.Errorf("Expected missing directory error, got %v: %s", err, out)
+	}
+
+	cmd = cliCommand([]string{"DB_PATH=:memory:"}, "-driver", "postgres", "version")
+	out, err = cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "Unknown driver") {
+		t.Errorf("Expected unknown driver error, got %v: %s", err, out)
+	}
+}
+
+func TestDropRemoteRequiresForce(t *testing.T) {
+	for _, url := range []string{"libsql://emails.turso.io", "https://emails.turso.io", "http://localhost:8080", "wss://emails.turso.io"} {
+		cmd := cliCommand([]string{"DB_PATH=" + url}, "drop", "-confirm")
+		out, err := cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(out), "without -force") {
+			t.Errorf("Expected %s to be refused without -force, got %v: %s", url, err, out)
+		}
+	}
+}
+
+func TestDumpSchemaCommand(t *testing.T) {
+	dir := writeMigrations(t)
+	dbPath := filepath.Join(t.TempDir(), "dump.db")
+	runCLI(t, dbPath, "-dir", dir, "up")
+
+	out := runCLI(t, dbPath, "dump-schema")
+	if !strings.Contains(string(out), "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);") {
+		t.Errorf("Expected users table in dump, got:\n%s", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	runCLI(t, dbPath, "dump-schema", "-out", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read dump: %v", err)
+	}
+	if !bytes.Equal(data, out) {
+		t.Errorf("Expected -out to match stdout, got:\n%s", data)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing/fstest"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// sourceFetchTimeout bounds downloading a migration source over HTTPS
+const sourceFetchTimeout = time.Minute
+
+// openSource returns the migrations at source, which is a directory path or
+// a file://, iofs:// or https:// URL. An https:// URL must serve a directory
+// index linking to each migration file, as http.FileServer and most static
+// file servers do, so that migrations can be kept on an artifact server
+// shared by several services. The files are downloaded up front.
+func openSource(ctx context.Context, source string, client *http.Client) (fs.FS, error) {
+	scheme, rest, found := strings.Cut(source, "://")
+	if !found {
+		return openDir(source)
+	}
+
+	switch scheme {
+	case "file", "iofs":
+		return openDir(rest)
+	case "https":
+		return fetchSource(ctx, source, client)
+	default:
+		return nil, fmt.Errorf("unsupported migration source scheme %q: use file://, iofs:// or https://", scheme)
+	}
+}
+
+// openDir returns the migrations in dir, which must exist
+func openDir(dir string) (fs.FS, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("opening migrations directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("opening migrations directory: %s is not a directory", dir)
+	}
+	return os.DirFS(dir), nil
+}
+
+// fetchSource downloads the .sql files listed in the directory index at
+// rawURL into memory
+func fetchSource(ctx context.Context, rawURL string, client *http.Client) (fs.FS, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing migration source: %w", err)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sourceFetchTimeout)
+	defer cancel()
+
+	index, err := fetch(ctx, client, base.String())
+	if err != nil {
+		return nil, fmt.Errorf("listing migrations: %w", err)
+	}
+
+	files := fstest.MapFS{}
+	for _, href := range links(index) {
+		ref, err := url.Parse(href)
+		if err != nil || ref.IsAbs() || ref.RawQuery != "" {
+			continue
+		}
+		name := ref.Path
+		if strings.Contains(name, "/") || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		data, err := fetch(ctx, client, base.ResolveReference(ref).String())
+		if err != nil {
+			return nil, fmt.Errorf("downloading %s: %w", name, err)
+		}
+		files[name] = &fstest.MapFile{Data: data, Mode: 0o444}
+	}
+	return files, nil
+}
+
+// links returns the href of every anchor in an HTML page
+func links(page []byte) []string {
+	var hrefs []string
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return hrefs
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if name, hasAttr := z.TagName(); string(name) != "a" || !hasAttr {
+				continue
+			}
+			for {
+				key, val, more := z.TagAttr()
+				if string(key) == "href" {
+					hrefs = append(hrefs, string(val))
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
+
+// fetch returns the body of a successful GET of rawURL
+func fetch(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
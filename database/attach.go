@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Attach attaches the database at path under alias.
+//
+// Attachments are scoped to a single connection, so they are only visible to
+// later statements that run on the same connection. Callers should either set
+// MaxOpenConns to 1 or attach on a dedicated connection obtained from Conn.
+func (db *DB) Attach(ctx context.Context, path, alias string) error {
+	quoted, err := quoteIdent(alias)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "ATTACH DATABASE ? AS "+quoted, path); err != nil {
+		return fmt.Errorf("attaching %s: %w", alias, err)
+	}
+	return nil
+}
+
+// Detach detaches the database previously attached under alias
+func (db *DB) Detach(ctx context.Context, alias string) error {
+	quoted, err := quoteIdent(alias)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "DETACH DATABASE "+quoted); err != nil {
+		return fmt.Errorf("detaching %s: %w", alias, err)
+	}
+	return nil
+}
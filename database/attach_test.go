@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAttach(t *testing.T) {
+	// Attachments are per-connection, so pin the pool to one connection
+	cfg := DefaultConfig()
+	cfg.MaxOpenConns = 1
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.Attach(ctx, ":memory:", "archive"); err != nil {
+		t.Fatalf("Failed to attach database: %v", err)
+	}
+
+	statements := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)",
+		"CREATE TABLE archive.emails (id INTEGER PRIMARY KEY, user_id INTEGER, subject TEXT)",
+		"INSERT INTO users (id, email) VALUES (1, 'alice@example.com'), (2, 'bob@example.com')",
+		"INSERT INTO archive.emails (user_id, subject) VALUES (1, 'old news'), (1, 'older news'), (2, 'hello')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to populate databases: %v", err)
+		}
+	}
+
+	var count int
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM users u
+		JOIN archive.emails e ON e.user_id = u.id
+		WHERE u.email = ?
+	`, "alice@example.com").Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to join across databases: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 archived emails, got %d", count)
+	}
+
+	if err := db.Detach(ctx, "archive"); err != nil {
+		t.Fatalf("Failed to detach database: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "SELECT * FROM archive.emails"); err == nil {
+		t.Error("Expected error querying detached database, got nil")
+	}
+
+	// Aliases are validated
+	if err := db.Attach(ctx, ":memory:", "x; DROP TABLE users"); err == nil {
+		t.Error("Expected error for invalid alias, got nil")
+	}
+}
@@ -0,0 +1,412 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Files kept in an incremental backup directory
+const (
+	backupManifestFile = "manifest.json"
+	backupHashesFile   = "pages.sha256"
+)
+
+// backupManifest describes an incremental backup chain. Each increment
+// holds the pages that changed since the one before it, so the first holds
+// every page.
+type backupManifest struct {
+	PageSize   int               `json:"page_size"`
+	Increments []backupIncrement `json:"increments"`
+}
+
+// backupIncrement is one link of a backup chain
+type backupIncrement struct {
+	File      string    `json:"file"`
+	Created   time.Time `json:"created"`
+	PageCount int       `json:"page_count"` // Size of the database in pages
+	Pages     int       `json:"pages"`      // Pages stored in File
+}
+
+// IncrementalBackup adds an increment to the backup chain in dir, storing
+// only the pages that changed since the previous increment; the first call
+// stores every page. The database is copied page for page with the SQLite
+// online backup API into a temporary snapshot in dir, so each increment is
+// a consistent snapshot of a committed state, taken while other connections
+// keep reading and writing. The snapshot is then streamed a page at a time
+// and compared against the page hashes of the previous increment, so memory
+// use does not grow with the database, and only changed pages are kept.
+// RestoreChain rebuilds the state of the latest increment.
+func (db *DB) IncrementalBackup(ctx context.Context, dir string) error {
+	if isMemory(db.cfg.Path) || isRemote(db.cfg.Path) {
+		return fmt.Errorf("backing up database: requires a local file database")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+
+	manifest, err := readBackupManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	// Take a page-exact snapshot to diff against the previous increment
+	snapshot, err := os.CreateTemp(dir, "snapshot-*.db")
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+	snapshot.Close()
+	defer os.Remove(snapshot.Name())
+
+	if err := db.backupToFile(ctx, snapshot.Name()); err != nil {
+		return err
+	}
+
+	src, err := os.Open(snapshot.Name())
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+	r := bufio.NewReader(src)
+	header, err := r.Peek(100)
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+	pageSize, err := headerPageSize(header)
+	if err != nil {
+		return err
+	}
+	if manifest.PageSize != 0 && manifest.PageSize != pageSize {
+		return fmt.Errorf("backing up database: page size changed from %d to %d; start a new chain", manifest.PageSize, pageSize)
+	}
+	manifest.PageSize = pageSize
+
+	// The hashes of the previous increment, read alongside the snapshot
+	var oldHashes *bufio.Reader
+	if f, err := os.Open(filepath.Join(dir, backupHashesFile)); err == nil {
+		defer f.Close()
+		oldHashes = bufio.NewReader(f)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading page hashes: %w", err)
+	}
+
+	// The new hashes replace the old ones only once the manifest is written
+	hashesTmp := filepath.Join(dir, backupHashesFile+".tmp")
+	hf, err := os.Create(hashesTmp)
+	if err != nil {
+		return fmt.Errorf("writing page hashes: %w", err)
+	}
+	defer os.Remove(hashesTmp)
+	defer hf.Close()
+	hw := bufio.NewWriter(hf)
+
+	pageCount := int(info.Size() / int64(pageSize))
+	name := fmt.Sprintf("%06d.pages", len(manifest.Increments)+1)
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return fmt.Errorf("creating increment: %w", err)
+	}
+	defer f.Close()
+
+	// Each changed page is stored as its big-endian page number and content
+	w := bufio.NewWriter(f)
+	page := make([]byte, pageSize)
+	old := make([]byte, sha256.Size)
+	changed := 0
+	for i := 0; i < pageCount; i++ {
+		if _, err := io.ReadFull(r, page); err != nil {
+			return fmt.Errorf("reading snapshot: %w", err)
+		}
+		sum := sha256.Sum256(page)
+		if _, err := hw.Write(sum[:]); err != nil {
+			return fmt.Errorf("writing page hashes: %w", err)
+		}
+
+		// A shorter hash file means the database grew since the last increment
+		if oldHashes != nil {
+			if _, err := io.ReadFull(oldHashes, old); err != nil {
+				oldHashes = nil
+			} else if bytes.Equal(old, sum[:]) {
+				continue
+			}
+		}
+		if err := binary.Write(w, binary.BigEndian, uint32(i+1)); err != nil {
+			return fmt.Errorf("writing increment: %w", err)
+		}
+		if _, err := w.Write(page); err != nil {
+			return fmt.Errorf("writing increment: %w", err)
+		}
+		changed++
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("writing increment: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("writing increment: %w", err)
+	}
+	if err := hw.Flush(); err != nil {
+		return fmt.Errorf("writing page hashes: %w", err)
+	}
+	if err := hf.Close(); err != nil {
+		return fmt.Errorf("writing page hashes: %w", err)
+	}
+
+	// The manifest is written before the hashes: if the backup fails between
+	// the two, the old hashes make the next increment store the pages changed
+	// since the previous one, a superset of what it needs
+	manifest.Increments = append(manifest.Increments, backupIncrement{
+		File:      name,
+		Created:   time.Now().UTC(),
+		PageCount: pageCount,
+		Pages:     changed,
+	})
+	if err := writeBackupManifest(dir, manifest); err != nil {
+		return err
+	}
+	if err := os.Rename(hashesTmp, filepath.Join(dir, backupHashesFile)); err != nil {
+		return fmt.Errorf("writing page hashes: %w", err)
+	}
+	return nil
+}
+
+// BackupStream writes a consistent copy of the database to w, for example to
+// pipe it through gzip or upload it without keeping a local copy. The copy
+// is taken with VACUUM INTO a temporary file, which is removed when done.
+func (db *DB) BackupStream(ctx context.Context, w io.Writer) error {
+	if isMemory(db.cfg.Path) || isRemote(db.cfg.Path) {
+		return fmt.Errorf("backing up database: requires a local file database")
+	}
+
+	dir, err := os.MkdirTemp("", "backup-")
+	if err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backup.db")
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("backing up database: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading backup: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("writing backup: %w", err)
+	}
+	return nil
+}
+
+// RestoreChain rebuilds the database recorded by the incremental backup
+// chain in dir at destPath, which must not already exist
+func RestoreChain(dir, destPath string) error {
+	manifest, err := readBackupManifest(dir)
+	if err != nil {
+		return err
+	}
+	if len(manifest.Increments) == 0 {
+		return fmt.Errorf("restoring backup: no increments in %s", dir)
+	}
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("restoring backup: %s already exists", destPath)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("restoring backup: %w", err)
+	}
+
+	// Assemble in a temporary file so destPath only appears once complete
+	tmp := destPath + ".restoring"
+	out, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("restoring backup: %w", err)
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+
+	page := make([]byte, manifest.PageSize)
+	for _, inc := range manifest.Increments {
+		if err := applyIncrement(filepath.Join(dir, inc.File), out, page); err != nil {
+			return fmt.Errorf("applying %s: %w", inc.File, err)
+		}
+		if err := out.Truncate(int64(inc.PageCount) * int64(manifest.PageSize)); err != nil {
+			return fmt.Errorf("applying %s: %w", inc.File, err)
+		}
+	}
+
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("restoring backup: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("restoring backup: %w", err)
+	}
+	if err := os.Rename(tmp, destPath); err != nil {
+		return fmt.Errorf("restoring backup: %w", err)
+	}
+	return nil
+}
+
+// applyIncrement writes the pages stored in path into out, using page as
+// the read buffer
+func applyIncrement(path string, out *os.File, page []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		var pgno uint32
+		if err := binary.Read(r, binary.BigEndian, &pgno); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, page); err != nil {
+			return fmt.Errorf("reading page %d: %w", pgno, err)
+		}
+		if _, err := out.WriteAt(page, int64(pgno-1)*int64(len(page))); err != nil {
+			return fmt.Errorf("writing page %d: %w", pgno, err)
+		}
+	}
+}
+
+// backupToFile copies the database page for page to path with the SQLite
+// online backup API
+func (db *DB) backupToFile(ctx context.Context, path string) error {
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	destConn, err := (&sqlite3.SQLiteDriver{}).Open(path)
+	if err != nil {
+		return fmt.Errorf("opening backup destination: %w", err)
+	}
+	defer destConn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		src, ok := sqliteConn(driverConn)
+		if !ok {
+			return fmt.Errorf("backing up database: unsupported driver connection %T", driverConn)
+		}
+		return copyPages(ctx, destConn.(*sqlite3.SQLiteConn), src)
+	})
+}
+
+// sqliteConn returns the sqlite3 connection under driverConn, which the
+// pool wraps
+func sqliteConn(driverConn any) (*sqlite3.SQLiteConn, bool) {
+	for {
+		switch c := driverConn.(type) {
+		case *sqlite3.SQLiteConn:
+			return c, true
+		case interface{ Unwrap() driver.Conn }:
+			driverConn = c.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// copyPages replaces the main database of dest with that of src using the
+// SQLite online backup API
+func copyPages(ctx context.Context, dest, src *sqlite3.SQLiteConn) error {
+	backup, err := dest.Backup("main", src, "main")
+	if err != nil {
+		return fmt.Errorf("starting backup: %w", err)
+	}
+
+	// Step returns without progress while either database is locked
+	for {
+		done, err := backup.Step(-1)
+		if err != nil {
+			backup.Close()
+			return fmt.Errorf("copying pages: %w", err)
+		}
+		if done {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			backup.Close()
+			return fmt.Errorf("copying pages: %w", ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if err := backup.Close(); err != nil {
+		return fmt.Errorf("finishing backup: %w", err)
+	}
+	return nil
+}
+
+// headerPageSize reads the page size from a database file header
+func headerPageSize(data []byte) (int, error) {
+	if len(data) < 100 || !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		return 0, fmt.Errorf("reading snapshot: not a SQLite database")
+	}
+	size := int(binary.BigEndian.Uint16(data[16:18]))
+	if size == 1 {
+		size = 65536
+	}
+	return size, nil
+}
+
+// readBackupManifest loads the manifest of dir, or an empty one if the chain
+// has not been started
+func readBackupManifest(dir string) (backupManifest, error) {
+	var manifest backupManifest
+	data, err := os.ReadFile(filepath.Join(dir, backupManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("reading backup manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("parsing backup manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// writeBackupManifest replaces the manifest of dir
+func writeBackupManifest(dir string, manifest backupManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding backup manifest: %w", err)
+	}
+	return writeBackupFile(dir, backupManifestFile, data, "backup manifest")
+}
+
+// writeBackupFile replaces the file name in dir with data through a
+// temporary file, so it is never left partly written
+func writeBackupFile(dir, name string, data []byte, what string) error {
+	tmp := filepath.Join(dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", what, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("writing %s: %w", what, err)
+	}
+	return nil
+}
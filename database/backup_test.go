@@ -0,0 +1,246 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// dumpEmails returns the rows of the emails table in id order
+func dumpEmails(t *testing.T, db *DB, ctx context.Context) []string {
+	t.Helper()
+
+	rows, err := db.QueryContext(ctx, "SELECT id, subject FROM emails ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query emails: %v", err)
+	}
+	defer rows.Close()
+
+	var dump []string
+	for rows.Next() {
+		var id int
+		var subject string
+		if err := rows.Scan(&id, &subject); err != nil {
+			t.Fatalf("Failed to scan email: %v", err)
+		}
+		dump = append(dump, fmt.Sprintf("%d %s", id, subject))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to read emails: %v", err)
+	}
+	return dump
+}
+
+func TestIncrementalBackup(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(dir, "emails.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A mostly static store: many rows written once
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT);
+		WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n WHERE x < 5000)
+		INSERT INTO emails (subject) SELECT 'email ' || x || ' ' || hex(randomblob(32)) FROM n;
+	`)
+	if err != nil {
+		t.Fatalf("Failed to set up: %v", err)
+	}
+
+	backups := filepath.Join(dir, "backups")
+	if err := db.IncrementalBackup(ctx, backups); err != nil {
+		t.Fatalf("Failed to take first backup: %v", err)
+	}
+
+	// Small changes between backups
+	changes := []string{
+		"INSERT INTO emails (subject) VALUES ('new arrival')",
+		"UPDATE emails SET subject = 'edited' WHERE id = 42",
+		"DELETE FROM emails WHERE id = 4999",
+	}
+	for _, stmt := range changes {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to change data: %v", err)
+		}
+		if err := db.IncrementalBackup(ctx, backups); err != nil {
+			t.Fatalf("Failed to take incremental backup: %v", err)
+		}
+	}
+
+	manifest, err := readBackupManifest(backups)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if len(manifest.Increments) != 4 {
+		t.Fatalf("Expected 4 increments, got %d", len(manifest.Increments))
+	}
+	full := manifest.Increments[0].Pages
+	for _, inc := range manifest.Increments[1:] {
+		if inc.Pages == 0 || inc.Pages*10 > full {
+			t.Errorf("Expected a small increment relative to %d pages, got %d", full, inc.Pages)
+		}
+	}
+
+	restored := filepath.Join(dir, "restored.db")
+	if err := RestoreChain(backups, restored); err != nil {
+		t.Fatalf("Failed to restore chain: %v", err)
+	}
+
+	rcfg := cfg
+	rcfg.Path = restored
+	rdb, err := Open(rcfg)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer rdb.Close()
+
+	problems, err := rdb.IntegrityCheck(ctx)
+	if err != nil {
+		t.Fatalf("Failed to check restored database: %v", err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("Expected a healthy restored database, got %v", problems)
+	}
+	if want, got := dumpEmails(t, db, ctx), dumpEmails(t, rdb, ctx); !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected restored rows to match, got %d rows instead of %d", len(got), len(want))
+	}
+
+	// The destination is never overwritten
+	if err := RestoreChain(backups, restored); err == nil {
+		t.Error("Expected error restoring over an existing file, got nil")
+	}
+	if _, err := os.Stat(restored + ".restoring"); !os.IsNotExist(err) {
+		t.Error("Expected no temporary file to remain")
+	}
+}
+
+func TestIncrementalBackupFailedManifest(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(dir, "emails.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT)"); err != nil {
+		t.Fatalf("Failed to set up: %v", err)
+	}
+	backups := filepath.Join(dir, "backups")
+	if err := db.IncrementalBackup(ctx, backups); err != nil {
+		t.Fatalf("Failed to take first backup: %v", err)
+	}
+
+	// A directory in the way of the temporary manifest fails the backup
+	// after its increment is written
+	if _, err := db.ExecContext(ctx, "INSERT INTO emails (subject) VALUES ('lost')"); err != nil {
+		t.Fatalf("Failed to change data: %v", err)
+	}
+	blocker := filepath.Join(backups, backupManifestFile+".tmp")
+	if err := os.Mkdir(blocker, 0o755); err != nil {
+		t.Fatalf("Failed to block manifest: %v", err)
+	}
+	if err := db.IncrementalBackup(ctx, backups); err == nil {
+		t.Fatal("Expected error writing manifest, got nil")
+	}
+	if err := os.Remove(blocker); err != nil {
+		t.Fatalf("Failed to unblock manifest: %v", err)
+	}
+
+	// The next increment still carries the change the failed one missed
+	if err := db.IncrementalBackup(ctx, backups); err != nil {
+		t.Fatalf("Failed to take incremental backup: %v", err)
+	}
+	restored := filepath.Join(dir, "restored.db")
+	if err := RestoreChain(backups, restored); err != nil {
+		t.Fatalf("Failed to restore chain: %v", err)
+	}
+
+	rcfg := cfg
+	rcfg.Path = restored
+	rdb, err := Open(rcfg)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer rdb.Close()
+
+	if want, got := dumpEmails(t, db, ctx), dumpEmails(t, rdb, ctx); !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected restored rows %v, got %v", want, got)
+	}
+}
+
+func TestBackupStream(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(dir, "emails.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT)",
+		"INSERT INTO emails (subject) VALUES ('first'), ('second')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := db.BackupStream(ctx, &buf); err != nil {
+		t.Fatalf("Failed to stream backup: %v", err)
+	}
+
+	// Load the bytes into a new database file
+	restored := filepath.Join(dir, "restored.db")
+	if err := os.WriteFile(restored, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write backup: %v", err)
+	}
+
+	rcfg := cfg
+	rcfg.Path = restored
+	rdb, err := Open(rcfg)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer rdb.Close()
+
+	if want, got := dumpEmails(t, db, ctx), dumpEmails(t, rdb, ctx); !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// In-memory databases are private to a connection and cannot be copied
+	mem, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer mem.Close()
+	if err := mem.BackupStream(ctx, &buf); err == nil {
+		t.Error("Expected error streaming an in-memory database, got nil")
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrIncrementalBlobUnsupported is returned when opening a blob for writing:
+// the linked SQLite driver does not expose sqlite3_blob_open, and emulating
+// incremental writes rewrites the whole value on every write
+var ErrIncrementalBlobUnsupported = errors.New("incremental blob I/O is not supported by this SQLite driver")
+
+// Blob reads part of a BLOB value at a time, opened with Session.OpenBlob
+type Blob struct {
+	ctx    context.Context
+	s      *Session
+	read   string // Query selecting a range of the value
+	rowid  int64
+	size   int64
+	offset int64
+}
+
+// OpenBlob opens the BLOB in column of the row of table with the given
+// rowid for reading, so large attachments can be copied with io.Copy
+// without holding them in memory. The linked SQLite driver does not expose
+// sqlite3_blob_open, so each Read fetches one range with substr and Go
+// memory is bounded by the buffer passed in. Writing is not supported:
+// opening with readonly false returns ErrIncrementalBlobUnsupported, so
+// store large values with a single INSERT or UPDATE. All I/O runs on the
+// session connection with ctx. A NULL or non-BLOB value is an error.
+func (s *Session) OpenBlob(ctx context.Context, table, column string, rowid int64, readonly bool) (io.ReadSeeker, error) {
+	if !readonly {
+		return nil, fmt.Errorf("opening blob %s.%s of row %d: %w", table, column, rowid, ErrIncrementalBlobUnsupported)
+	}
+	quotedTable, err := quoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+	quotedColumn, err := quoteIdent(column)
+	if err != nil {
+		return nil, err
+	}
+
+	var kind string
+	var size int64
+	err = s.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT typeof(%[1]s), length(%[1]s) FROM %[2]s WHERE rowid = ?", quotedColumn, quotedTable),
+		rowid).Scan(&kind, &size)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("opening blob %s.%s of row %d: %w", table, column, rowid, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening blob %s.%s of row %d: %w", table, column, rowid, err)
+	}
+	if kind != "blob" {
+		return nil, fmt.Errorf("opening blob %s.%s of row %d: value is %s, not blob", table, column, rowid, kind)
+	}
+
+	return &Blob{
+		ctx:   ctx,
+		s:     s,
+		read:  fmt.Sprintf("SELECT substr(%s, ?, ?) FROM %s WHERE rowid = ?", quotedColumn, quotedTable),
+		rowid: rowid,
+		size:  size,
+	}, nil
+}
+
+// Size returns the length of the blob in bytes
+func (b *Blob) Size() int64 {
+	return b.size
+}
+
+// Read implements io.Reader
+func (b *Blob) Read(p []byte) (int, error) {
+	if b.offset >= b.size {
+		return 0, io.EOF
+	}
+	n := min(int64(len(p)), b.size-b.offset)
+	if n == 0 {
+		return 0, nil
+	}
+
+	var chunk []byte
+	if err := b.s.QueryRowContext(b.ctx, b.read, b.offset+1, n, b.rowid).Scan(&chunk); err != nil {
+		return 0, fmt.Errorf("reading blob: %w", err)
+	}
+	copied := copy(p, chunk)
+	b.offset += int64(copied)
+	if int64(copied) < n {
+		return copied, io.ErrUnexpectedEOF
+	}
+	return copied, nil
+}
+
+// Seek implements io.Seeker
+func (b *Blob) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.offset
+	case io.SeekEnd:
+		offset += b.size
+	default:
+		return 0, fmt.Errorf("seeking blob: invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("seeking blob: negative offset %d", offset)
+	}
+	b.offset = offset
+	return offset, nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestOpenBlob(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s, err := db.Session(ctx)
+	if err != nil {
+		t.Fatalf("Failed to open session: %v", err)
+	}
+	defer s.Close()
+
+	const size = 3 << 20
+	_, err = s.ExecContext(ctx, "CREATE TABLE attachments (id INTEGER PRIMARY KEY, data BLOB, name TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	want := make([]byte, size)
+	rand.Read(want)
+	if _, err := s.ExecContext(ctx, "INSERT INTO attachments (id, data, name) VALUES (1, ?, 'a.bin')", want); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+
+	// Writing is not supported by the driver
+	if _, err := s.OpenBlob(ctx, "attachments", "data", 1, false); !errors.Is(err, ErrIncrementalBlobUnsupported) {
+		t.Errorf("Expected ErrIncrementalBlobUnsupported, got %v", err)
+	}
+
+	// Read back in smaller chunks
+	r, err := s.OpenBlob(ctx, "attachments", "data", 1, true)
+	if err != nil {
+		t.Fatalf("Failed to open blob: %v", err)
+	}
+	var got bytes.Buffer
+	if _, err := io.CopyBuffer(&got, r, make([]byte, 64<<10)); err != nil {
+		t.Fatalf("Failed to read blob: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("Read %d bytes that differ from the %d written", got.Len(), size)
+	}
+
+	// Seek and read a range
+	if _, err := r.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	tail, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(tail, want[size-10:]) {
+		t.Errorf("Expected last 10 bytes, got %d: %v", len(tail), err)
+	}
+
+	if _, err := s.OpenBlob(ctx, "attachments", "data", 2, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing row, got %v", err)
+	}
+	if _, err := s.OpenBlob(ctx, "attachments", "name", 1, true); err == nil {
+		t.Error("Expected error opening a TEXT value, got nil")
+	}
+	if _, err := s.OpenBlob(ctx, "attachments; --", "data", 1, true); err == nil {
+		t.Error("Expected error for an invalid table name, got nil")
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Bool stores a flag such as is_read in an INTEGER column as 0 or 1.
+// SQLite has no boolean type and the drivers disagree on how to write Go
+// bools, so Bool also reads the other common forms.
+type Bool bool
+
+// Value implements driver.Valuer by storing 0 or 1
+func (b Bool) Value() (driver.Value, error) {
+	if b {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+// Scan implements sql.Scanner. It accepts the integers 0 and 1, driver
+// bools, and the text "0", "1", "true" and "false" in any case, so columns
+// written by raw SQL or other tools read the same. NULL leaves false.
+func (b *Bool) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*b = false
+	case bool:
+		*b = Bool(v)
+	case int64:
+		if v != 0 && v != 1 {
+			return fmt.Errorf("cannot scan %d into Bool", v)
+		}
+		*b = v == 1
+	case float64:
+		if v != 0 && v != 1 {
+			return fmt.Errorf("cannot scan %v into Bool", v)
+		}
+		*b = v == 1
+	case []byte:
+		return b.Scan(string(v))
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "1", "true":
+			*b = true
+		case "0", "false":
+			*b = false
+		default:
+			return fmt.Errorf("cannot scan %q into Bool", v)
+		}
+	default:
+		return fmt.Errorf("cannot scan %T into Bool", src)
+	}
+	return nil
+}
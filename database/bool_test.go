@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBoolRoundTrip(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, is_read)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Written by the app, and by raw SQL in the forms other tools use
+	if _, err := db.ExecContext(ctx, "INSERT INTO emails (id, is_read) VALUES (1, ?), (2, ?)", Bool(true), Bool(false)); err != nil {
+		t.Fatalf("Failed to insert flags: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO emails (id, is_read) VALUES
+		(3, 'true'), (4, 'FALSE'), (5, '1'), (6, x'30'), (7, TRUE), (8, 0.0), (9, NULL)`); err != nil {
+		t.Fatalf("Failed to insert raw flags: %v", err)
+	}
+
+	var stored []int64
+	rows, err := db.QueryContext(ctx, "SELECT is_read FROM emails WHERE id <= 2 ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	for rows.Next() {
+		var v any
+		rows.Scan(&v)
+		n, ok := v.(int64)
+		if !ok {
+			t.Errorf("Expected INTEGER storage, got %T", v)
+		}
+		stored = append(stored, n)
+	}
+	rows.Close()
+	if len(stored) != 2 || stored[0] != 1 || stored[1] != 0 {
+		t.Errorf("Expected 1 and 0 stored, got %v", stored)
+	}
+
+	want := map[int64]Bool{1: true, 2: false, 3: true, 4: false, 5: true, 6: false, 7: true, 8: false, 9: false}
+	for id, expected := range want {
+		var got Bool
+		if err := db.QueryRowContext(ctx, "SELECT is_read FROM emails WHERE id = ?", id).Scan(&got); err != nil {
+			t.Errorf("Row %d: failed to scan: %v", id, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("Row %d: expected %v, got %v", id, expected, got)
+		}
+	}
+}
+
+func TestBoolScan(t *testing.T) {
+	for _, src := range []any{int64(1), true, "true", "True", " 1 ", []byte("true"), float64(1)} {
+		var b Bool
+		if err := b.Scan(src); err != nil || !b {
+			t.Errorf("Expected true from %#v, got %v: %v", src, b, err)
+		}
+	}
+	for _, src := range []any{int64(0), false, "false", "0", []byte("0"), float64(0), nil} {
+		b := Bool(true)
+		if err := b.Scan(src); err != nil || b {
+			t.Errorf("Expected false from %#v, got %v: %v", src, b, err)
+		}
+	}
+	for _, src := range []any{int64(2), int64(-1), "yes", "", float64(0.5), time.Now()} {
+		var b Bool
+		if err := b.Scan(src); err == nil {
+			t.Errorf("Expected error scanning %#v, got nil", src)
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		IsRead Bool `json:"is_read"`
+	}{true})
+	if err != nil || string(data) != `{"is_read":true}` {
+		t.Errorf("Expected JSON boolean, got %s: %v", data, err)
+	}
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// defaultQueryCacheSize is used when Config.QueryCacheSize is not set
+const defaultQueryCacheSize = 1024
+
+// queryCache is a size-bounded LRU of scanned results with per-entry expiry
+type queryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Front is most recently used
+	entries map[string]*list.Element
+}
+
+// cacheEntry is a cached result held in queryCache.order
+type cacheEntry struct {
+	key     string
+	value   reflect.Value
+	expires time.Time
+}
+
+// newQueryCache returns an empty cache holding at most size entries
+func newQueryCache(size int) *queryCache {
+	if size <= 0 {
+		size = defaultQueryCacheSize
+	}
+	return &queryCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get returns the unexpired value stored under key
+func (c *queryCache) get(key string, now time.Time) (reflect.Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return reflect.Value{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set stores value under key until expires, evicting the least recently
+// used entry if the cache is full
+func (c *queryCache) set(key string, value reflect.Value, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &cacheEntry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// remove deletes the given keys, or every entry when none are given
+func (c *queryCache) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(keys) == 0 {
+		c.order.Init()
+		clear(c.entries)
+		return
+	}
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// CachedQueryRow works like Get, but keeps the scanned result in memory under
+// key for ttl and serves later calls from there without running the query.
+// Cached values are shallow copies, so slices in dest must not be modified.
+// Call InvalidateCache after writes that change the result.
+func (db *DB) CachedQueryRow(ctx context.Context, key string, ttl time.Duration, dest any, query string, args ...any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("scan destination must be a non-nil pointer, got %T", dest)
+	}
+
+	if cached, ok := db.cache.get(key, time.Now()); ok {
+		if cached.Type() != v.Elem().Type() {
+			return fmt.Errorf("cache key %q holds %s, not %s", key, cached.Type(), v.Elem().Type())
+		}
+		v.Elem().Set(cached)
+		return nil
+	}
+
+	if err := db.Get(ctx, dest, query, args...); err != nil {
+		return err
+	}
+
+	value := reflect.New(v.Elem().Type()).Elem()
+	value.Set(v.Elem())
+	db.cache.set(key, value, time.Now().Add(ttl))
+	return nil
+}
+
+// InvalidateCache removes keys from the CachedQueryRow cache, or clears it
+// entirely when called without keys
+func (db *DB) InvalidateCache(keys ...string) {
+	db.cache.remove(keys...)
+}
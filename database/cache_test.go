@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// settings is the cached row type used by the cache tests
+type settings struct {
+	AccountID int64  `db:"account_id"`
+	Signature string `db:"signature"`
+}
+
+// openCacheTestDB opens a database with a settings table and counts queries
+func openCacheTestDB(t *testing.T, size int) (*DB, *int) {
+	t.Helper()
+
+	queries := 0
+	cfg := DefaultConfig()
+	cfg.QueryCacheSize = size
+	cfg.Hooks.BeforeQuery = func(ctx context.Context, query string, args []any) context.Context {
+		queries++
+		return ctx
+	}
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	statements := []string{
+		"CREATE TABLE settings (account_id INTEGER PRIMARY KEY, signature TEXT)",
+		"INSERT INTO settings VALUES (1, 'Regards'), (2, 'Cheers'), (3, 'Thanks')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+	queries = 0
+	return db, &queries
+}
+
+func TestCachedQueryRow(t *testing.T) {
+	db, queries := openCacheTestDB(t, 0)
+	ctx := context.Background()
+	const query = "SELECT account_id, signature FROM settings WHERE account_id = ?"
+
+	var s settings
+	if err := db.CachedQueryRow(ctx, "settings:1", time.Minute, &s, query, 1); err != nil {
+		t.Fatalf("Failed to query settings: %v", err)
+	}
+	if _, err := db.Exec("UPDATE settings SET signature = 'Best' WHERE account_id = 1"); err != nil {
+		t.Fatalf("Failed to update settings: %v", err)
+	}
+
+	// A hit is served from memory, so the stale value is returned
+	var hit settings
+	if err := db.CachedQueryRow(ctx, "settings:1", time.Minute, &hit, query, 1); err != nil {
+		t.Fatalf("Failed to query settings: %v", err)
+	}
+	if hit != (settings{1, "Regards"}) {
+		t.Errorf("Expected cached settings, got %+v", hit)
+	}
+	if *queries != 2 {
+		t.Errorf("Expected query and update only, got %d statements", *queries)
+	}
+
+	// Invalidating the key reads the new value
+	db.InvalidateCache("settings:1")
+	if err := db.CachedQueryRow(ctx, "settings:1", time.Minute, &hit, query, 1); err != nil {
+		t.Fatalf("Failed to query settings: %v", err)
+	}
+	if hit.Signature != "Best" {
+		t.Errorf("Expected fresh settings after invalidation, got %+v", hit)
+	}
+
+	// Missing rows are reported and not cached
+	err := db.CachedQueryRow(ctx, "settings:9", time.Minute, &hit, query, 9)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	// Reusing a key with a different type is an error
+	var signature string
+	if err := db.CachedQueryRow(ctx, "settings:1", time.Minute, &signature, query, 1); err == nil {
+		t.Error("Expected error for mismatched destination type, got nil")
+	}
+}
+
+func TestCachedQueryRowExpiry(t *testing.T) {
+	db, queries := openCacheTestDB(t, 0)
+	ctx := context.Background()
+
+	var signature string
+	for i := 0; i < 2; i++ {
+		if err := db.CachedQueryRow(ctx, "sig", 20*time.Millisecond, &signature, "SELECT signature FROM settings WHERE account_id = 1"); err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+	}
+	if *queries != 1 {
+		t.Errorf("Expected 1 query before expiry, got %d", *queries)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := db.CachedQueryRow(ctx, "sig", 20*time.Millisecond, &signature, "SELECT signature FROM settings WHERE account_id = 1"); err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if *queries != 2 {
+		t.Errorf("Expected expired entry to be queried again, got %d queries", *queries)
+	}
+}
+
+func TestCachedQueryRowEviction(t *testing.T) {
+	db, queries := openCacheTestDB(t, 2)
+	ctx := context.Background()
+
+	get := func(id int) {
+		var s settings
+		key := fmt.Sprintf("settings:%d", id)
+		if err := db.CachedQueryRow(ctx, key, time.Minute, &s, "SELECT * FROM settings WHERE account_id = ?", id); err != nil {
+			t.Fatalf("Failed to query %s: %v", key, err)
+		}
+	}
+
+	get(1)
+	get(2)
+	get(1) // Hit; 2 becomes least recently used
+	get(3) // Evicts 2
+	if *queries != 3 {
+		t.Fatalf("Expected 3 queries, got %d", *queries)
+	}
+
+	get(1)
+	if *queries != 3 {
+		t.Errorf("Expected recently used entry to survive eviction, got %d queries", *queries)
+	}
+	get(2)
+	if *queries != 4 {
+		t.Errorf("Expected least recently used entry to be evicted, got %d queries", *queries)
+	}
+
+	// Clearing everything forces a query
+	db.InvalidateCache()
+	get(1)
+	if *queries != 5 {
+		t.Errorf("Expected query after clearing the cache, got %d queries", *queries)
+	}
+}
+
+func TestCachedQueryRowConcurrent(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.QueryCacheSize = 4
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var n int
+			key := fmt.Sprintf("n:%d", i%8)
+			if err := db.CachedQueryRow(context.Background(), key, time.Minute, &n, "SELECT ?", i%8); err != nil {
+				t.Errorf("Failed to query: %v", err)
+			}
+			if n != i%8 {
+				t.Errorf("Expected %d, got %d", i%8, n)
+			}
+			if i%5 == 0 {
+				db.InvalidateCache(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql/driver"
+	"fmt"
+	"io"
+)
+
+// gzipMagic starts every gzip stream: the ID bytes and the deflate method
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// CompressedBlob stores Data gzip-compressed in a BLOB column, for large
+// text such as email bodies. Values that are not gzip streams, such as rows
+// written before compression was enabled, are read as they are.
+type CompressedBlob struct {
+	Data []byte
+	// Level is the gzip level from gzip.BestSpeed to gzip.BestCompression;
+	// 0 uses gzip.DefaultCompression
+	Level int
+}
+
+// Value implements driver.Valuer by compressing Data. A nil Data is stored
+// as NULL.
+func (c CompressedBlob) Value() (driver.Value, error) {
+	if c.Data == nil {
+		return nil, nil
+	}
+
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, fmt.Errorf("compressing blob: %w", err)
+	}
+	if _, err := w.Write(c.Data); err != nil {
+		return nil, fmt.Errorf("compressing blob: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("compressing blob: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Scan implements sql.Scanner by decompressing gzip data and copying any
+// other BLOB or TEXT as is. NULL leaves Data nil.
+func (c *CompressedBlob) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		c.Data = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into CompressedBlob", src)
+	}
+
+	if !bytes.HasPrefix(data, gzipMagic) {
+		c.Data = bytes.Clone(data)
+		return nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decompressing blob: %w", err)
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("decompressing blob: %w", err)
+	}
+	c.Data = out
+	return nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressedBlob(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, body BLOB)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	body := []byte(strings.Repeat("Hello, this is a fairly repetitive email body.\n", 500))
+	bodies := map[int]CompressedBlob{
+		1: {Data: body},
+		2: {Data: body, Level: gzip.BestCompression},
+		3: {Data: body, Level: gzip.BestSpeed},
+		4: {Data: []byte{}},
+		5: {},
+	}
+	for id, b := range bodies {
+		if _, err := db.ExecContext(ctx, "INSERT INTO emails (id, body) VALUES (?, ?)", id, b); err != nil {
+			t.Fatalf("Failed to insert body %d: %v", id, err)
+		}
+	}
+
+	// Rows written before compression, as BLOB and as TEXT
+	if _, err := db.ExecContext(ctx, "INSERT INTO emails (id, body) VALUES (6, ?), (7, 'legacy text')", []byte("legacy blob")); err != nil {
+		t.Fatalf("Failed to insert legacy rows: %v", err)
+	}
+
+	var stored int
+	if err := db.QueryRowContext(ctx, "SELECT length(body) FROM emails WHERE id = 1").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read stored size: %v", err)
+	}
+	if stored >= len(body)/10 {
+		t.Errorf("Expected stored size well below %d bytes, got %d", len(body), stored)
+	}
+
+	want := map[int][]byte{1: body, 2: body, 3: body, 4: {}, 5: nil, 6: []byte("legacy blob"), 7: []byte("legacy text")}
+	for id, expected := range want {
+		var got CompressedBlob
+		if err := db.QueryRowContext(ctx, "SELECT body FROM emails WHERE id = ?", id).Scan(&got); err != nil {
+			t.Errorf("Row %d: failed to scan: %v", id, err)
+			continue
+		}
+		if !bytes.Equal(got.Data, expected) || (expected == nil) != (got.Data == nil) {
+			t.Errorf("Row %d: expected %d bytes, got %d", id, len(expected), len(got.Data))
+		}
+	}
+
+	if _, err := (CompressedBlob{Data: body, Level: 42}).Value(); err == nil {
+		t.Error("Expected error for an invalid level, got nil")
+	}
+	var corrupt CompressedBlob
+	if err := corrupt.Scan(append(append([]byte{}, gzipMagic...), 0, 1, 2)); err == nil {
+		t.Error("Expected error scanning a corrupt gzip stream, got nil")
+	}
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// connector opens local connections through the sqlite3 driver. Pragmas
+// passed in the DSN are not reliably applied by the driver, so they are
+// also executed explicitly on every new connection.
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+// newConnector returns a connector for a local DSN applying pragmas on connect
+func newConnector(dsn string, pragmas Pragmas) (*connector, error) {
+	// Sort keys so pragmas are applied in a deterministic order
+	keys := make([]string, 0, len(pragmas))
+	for key, value := range pragmas {
+		if err := validateIdent(key); err != nil {
+			return nil, fmt.Errorf("invalid pragma: %w", err)
+		}
+		if !pragmaValuePattern.MatchString(value) {
+			return nil, fmt.Errorf("invalid value %q for pragma %s", value, key)
+		}
+		if err := validatePragmaValue(key, value); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Storage pragmas go first and in order, before auto_vacuum or
+	// journal_mode fix the page size
+	rank := func(key string) int {
+		if i := slices.Index(storagePragmas, key); i >= 0 {
+			return i
+		}
+		return len(storagePragmas)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return rank(keys[i]) < rank(keys[j])
+	})
+
+	drv := &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			for _, key := range keys {
+				if _, err := conn.Exec(fmt.Sprintf("PRAGMA %s = %s", key, pragmas[key]), nil); err != nil {
+					return fmt.Errorf("applying pragma %s: %w", key, err)
+				}
+			}
+			return nil
+		},
+	}
+
+	return &connector{dsn: dsn, driver: drv}, nil
+}
+
+// Connect implements driver.Connector
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver implements driver.Connector
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// hookConnector runs a callback on every new connection of the wrapped
+// connector before the pool sees it
+type hookConnector struct {
+	driver.Connector
+	onConnect func(ctx context.Context, conn *sql.Conn) error
+}
+
+// Connect implements driver.Connector. The new connection is lent to
+// onConnect through a single-connection pool of its own; if onConnect fails
+// the connection is closed and the error returned.
+func (c *hookConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	setup := sql.OpenDB(&lentConnector{conn: lentConn{conn}, driver: c.Driver()})
+	setup.SetMaxOpenConns(1)
+	sqlConn, err := setup.Conn(ctx)
+	if err == nil {
+		err = c.onConnect(ctx, sqlConn)
+		sqlConn.Close()
+	}
+	setup.Close()
+
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("running OnConnect: %w", err)
+	}
+	return conn, nil
+}
+
+// lentConnector hands out one borrowed connection exactly once
+type lentConnector struct {
+	conn   driver.Conn
+	driver driver.Driver
+	used   bool
+}
+
+// Connect implements driver.Connector
+func (c *lentConnector) Connect(_ context.Context) (driver.Conn, error) {
+	if c.used {
+		return nil, errors.New("connection already lent")
+	}
+	c.used = true
+	return c.conn, nil
+}
+
+// Driver implements driver.Connector
+func (c *lentConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// lentConn is a connection borrowed for OnConnect; closing it leaves the
+// underlying connection open for the pool
+type lentConn struct {
+	driver.Conn
+}
+
+// Close implements driver.Conn without closing the borrowed connection
+func (c lentConn) Close() error {
+	return nil
+}
+
+// ExecContext implements driver.ExecerContext when the connection does,
+// so statements need not be prepared
+func (c lentConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// QueryContext implements driver.QueryerContext when the connection does
+func (c lentConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// PrepareContext implements driver.ConnPrepareContext when the connection does
+func (c lentConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx implements driver.ConnBeginTx when the connection does
+func (c lentConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// CheckNamedValue implements driver.NamedValueChecker when the connection
+// does, so driver-specific argument types keep working
+func (c lentConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnConnect(t *testing.T) {
+	var calls atomic.Int32
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "hooks.db")
+	cfg.MaxOpenConns = 4
+	cfg.MaxIdleConns = 4
+	cfg.OnConnect = func(ctx context.Context, conn *sql.Conn) error {
+		calls.Add(1)
+		// Temp views are private to a connection, so each one needs its own
+		_, err := conn.ExecContext(ctx, "CREATE TEMP VIEW conn_setup AS SELECT 1 AS ready")
+		return err
+	}
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Hold connections at once so the pool has to grow
+	var conns []*sql.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get connection: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+
+		var ready int
+		if err := conn.QueryRowContext(ctx, "SELECT ready FROM conn_setup").Scan(&ready); err != nil {
+			t.Fatalf("Failed to query setup view on connection %d: %v", i, err)
+		}
+	}
+
+	if got := calls.Load(); got != int32(len(conns)) {
+		t.Errorf("Expected OnConnect to run %d times, got %d", len(conns), got)
+	}
+
+	// Reusing pooled connections does not run it again
+	for _, conn := range conns {
+		conn.Close()
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := db.ExecContext(ctx, "SELECT ready FROM conn_setup"); err != nil {
+			t.Fatalf("Failed to query setup view: %v", err)
+		}
+	}
+	if got := calls.Load(); got != int32(len(conns)) {
+		t.Errorf("Expected OnConnect to still have run %d times, got %d", len(conns), got)
+	}
+}
+
+func TestOnConnectError(t *testing.T) {
+	errSetup := errors.New("setup failed")
+
+	cfg := DefaultConfig()
+	cfg.OnConnect = func(ctx context.Context, conn *sql.Conn) error {
+		return errSetup
+	}
+
+	db, err := Open(cfg)
+	if err == nil {
+		db.Close()
+		t.Fatal("Expected error opening database, got nil")
+	}
+	if !errors.Is(err, errSetup) {
+		t.Errorf("Expected OnConnect error, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConflictMode selects what an import does with rows that violate a
+// uniqueness constraint
+type ConflictMode int
+
+const (
+	// ConflictError aborts the import and rolls back all rows
+	ConflictError ConflictMode = iota
+	// ConflictSkip keeps the existing row and ignores the imported one
+	ConflictSkip
+	// ConflictUpsert updates the existing row with the imported values
+	ConflictUpsert
+)
+
+// ImportOptions configures ImportCSV and ImportJSONL
+type ImportOptions struct {
+	OnConflict ConflictMode
+	// ConflictColumns is the unique key used to detect conflicts when
+	// upserting; it defaults to the table's primary key
+	ConflictColumns []string
+}
+
+// ExportCSV runs query and writes the result set to w as CSV, starting with
+// a header row of column names. NULLs are written as empty fields and BLOBs
+// are base64-encoded.
+func (db *DB) ExportCSV(ctx context.Context, w io.Writer, query string, args ...any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("running export query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("reading columns: %w", err)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("scanning row: %w", err)
+		}
+		for i, v := range values {
+			record[i] = csvField(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing row: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating rows: %w", err)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("flushing CSV: %w", err)
+	}
+	return nil
+}
+
+// csvField formats a scanned column value as a CSV field
+func csvField(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ImportCSV reads CSV with a header row from r and inserts each record into
+// table within a single transaction, returning the number of rows inserted
+// or updated. Header names must match columns of table. Fields are trimmed
+// of surrounding whitespace, empty fields become NULL, and fields for BLOB
+// columns are base64-decoded, mirroring ExportCSV.
+func (db *DB) ImportCSV(ctx context.Context, r io.Reader, table string, opts ImportOptions) (int64, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("reading header: empty input")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	ins, err := prepareImport(ctx, tx, table, header, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, ins.query)
+	if err != nil {
+		return 0, fmt.Errorf("preparing insert into %s: %w", table, err)
+	}
+	defer stmt.Close()
+
+	var count int64
+	args := make([]any, len(header))
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading CSV: %w", err)
+		}
+
+		for i, field := range record {
+			field = strings.TrimSpace(field)
+			switch {
+			case field == "":
+				args[i] = nil
+			case ins.blob[i]:
+				data, err := base64.StdEncoding.DecodeString(field)
+				if err != nil {
+					return 0, fmt.Errorf("line %d: decoding %s: %w", line, header[i], err)
+				}
+				args[i] = data
+			default:
+				args[i] = field
+			}
+		}
+
+		res, err := stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: inserting into %s: %w", line, table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		count += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing import: %w", err)
+	}
+	return count, nil
+}
+
+// importStatement is the insert used by ImportCSV
+type importStatement struct {
+	query string
+	blob  []bool // Whether each header column is a BLOB
+}
+
+// prepareImport validates header against the columns of table, read through
+// q, and builds the insert statement for the conflict mode
+func prepareImport(ctx context.Context, q Querier, table string, header []string, opts ImportOptions) (importStatement, error) {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return importStatement{}, err
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT name, type, pk FROM pragma_table_info(?)", table)
+	if err != nil {
+		return importStatement{}, fmt.Errorf("reading columns of %s: %w", table, err)
+	}
+	types := map[string]string{}
+	var primaryKey []string
+	for rows.Next() {
+		var name, typ string
+		var pk int
+		if err := rows.Scan(&name, &typ, &pk); err != nil {
+			rows.Close()
+			return importStatement{}, fmt.Errorf("scanning column: %w", err)
+		}
+		types[name] = strings.ToUpper(typ)
+		if pk > 0 {
+			primaryKey = append(primaryKey, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return importStatement{}, fmt.Errorf("reading columns of %s: %w", table, err)
+	}
+	if len(types) == 0 {
+		return importStatement{}, fmt.Errorf("table %s does not exist", table)
+	}
+
+	ins := importStatement{blob: make([]bool, len(header))}
+	cols := make([]string, len(header))
+	for i, name := range header {
+		typ, ok := types[name]
+		if !ok {
+			return importStatement{}, fmt.Errorf("column %q not found in %s", name, table)
+		}
+		if cols[i], err = quoteIdent(name); err != nil {
+			return importStatement{}, err
+		}
+		ins.blob[i] = strings.Contains(typ, "BLOB")
+	}
+
+	verb := "INSERT"
+	if opts.OnConflict == ConflictSkip {
+		verb = "INSERT OR IGNORE"
+	}
+	ins.query = fmt.Sprintf("%s INTO %s (%s) VALUES (%s)",
+		verb, quoted, strings.Join(cols, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "))
+
+	if opts.OnConflict == ConflictUpsert {
+		keys := opts.ConflictColumns
+		if len(keys) == 0 {
+			keys = primaryKey
+		}
+		if len(keys) == 0 {
+			return importStatement{}, fmt.Errorf("table %s has no primary key to upsert on", table)
+		}
+
+		isKey := map[string]bool{}
+		target := make([]string, len(keys))
+		for i, key := range keys {
+			if target[i], err = quoteIdent(key); err != nil {
+				return importStatement{}, err
+			}
+			isKey[key] = true
+		}
+
+		var updates []string
+		for i, name := range header {
+			if !isKey[name] {
+				updates = append(updates, cols[i]+" = excluded."+cols[i])
+			}
+		}
+
+		ins.query += " ON CONFLICT (" + strings.Join(target, ", ") + ")"
+		if len(updates) == 0 {
+			ins.query += " DO NOTHING"
+		} else {
+			ins.query += " DO UPDATE SET " + strings.Join(updates, ", ")
+		}
+	}
+
+	return ins, nil
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportCSV(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		`CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT, size REAL, raw BLOB)`,
+		`INSERT INTO emails (subject, size, raw) VALUES ('Hello, world', 1.5, x'00ff10')`,
+		`INSERT INTO emails (subject, size, raw) VALUES ('Quote "this"', NULL, NULL)`,
+		`INSERT INTO emails (subject, size, raw) VALUES ('archived', 3, NULL)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = db.ExportCSV(ctx, &buf, "SELECT id, subject, size, raw FROM emails WHERE subject != ? ORDER BY id", "archived")
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse exported CSV: %v", err)
+	}
+
+	expected := [][]string{
+		{"id", "subject", "size", "raw"},
+		{"1", "Hello, world", "1.5", base64.StdEncoding.EncodeToString([]byte{0x00, 0xff, 0x10})},
+		{"2", `Quote "this"`, "", ""},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, got %v", expected, records)
+	}
+}
+
+// setupContacts creates a contacts table for import tests
+func setupContacts(t *testing.T, db *DB, ctx context.Context) {
+	t.Helper()
+
+	_, err := db.ExecContext(ctx, `CREATE TABLE contacts (
+		id INTEGER PRIMARY KEY,
+		email TEXT UNIQUE NOT NULL,
+		name TEXT,
+		avatar BLOB
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	setupContacts(t, db, ctx)
+
+	// Extra whitespace, a quoted comma, an empty field and a BLOB
+	input := " email , name , avatar\n" +
+		"  ada@example.com ,  Ada Lovelace  , AP8Q\n" +
+		`grace@example.com, "Hopper, Grace",` + "\n"
+
+	n, err := db.ImportCSV(ctx, strings.NewReader(input), "contacts", ImportOptions{})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 rows imported, got %d", n)
+	}
+
+	var name string
+	var avatar []byte
+	err = db.QueryRowContext(ctx, "SELECT name, avatar FROM contacts WHERE email = 'ada@example.com'").Scan(&name, &avatar)
+	if err != nil {
+		t.Fatalf("Failed to read imported row: %v", err)
+	}
+	if name != "Ada Lovelace" || !bytes.Equal(avatar, []byte{0x00, 0xff, 0x10}) {
+		t.Errorf("Expected trimmed name and decoded avatar, got %q and %v", name, avatar)
+	}
+
+	var nullAvatar bool
+	err = db.QueryRowContext(ctx, "SELECT name, avatar IS NULL FROM contacts WHERE email = 'grace@example.com'").Scan(&name, &nullAvatar)
+	if err != nil {
+		t.Fatalf("Failed to read imported row: %v", err)
+	}
+	if name != "Hopper, Grace" || !nullAvatar {
+		t.Errorf("Expected quoted comma and NULL avatar, got %q and %v", name, nullAvatar)
+	}
+
+	// Unknown columns are rejected
+	_, err = db.ImportCSV(ctx, strings.NewReader("email,phone\nx@example.com,123\n"), "contacts", ImportOptions{})
+	if err == nil {
+		t.Error("Expected error for unknown column, got nil")
+	}
+}
+
+func TestImportCSVConflicts(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	setupContacts(t, db, ctx)
+	if _, err := db.ExecContext(ctx, "INSERT INTO contacts (email, name) VALUES ('ada@example.com', 'Ada')"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	input := "email,name\nada@example.com,Ada Lovelace\nalan@example.com,Alan Turing\n"
+
+	// By default a conflict aborts the whole import
+	if _, err := db.ImportCSV(ctx, strings.NewReader(input), "contacts", ImportOptions{}); err == nil {
+		t.Fatal("Expected conflict error, got nil")
+	}
+	if count, _ := db.Count(ctx, "contacts", ""); count != 1 {
+		t.Errorf("Expected failed import to roll back, got %d rows", count)
+	}
+
+	n, err := db.ImportCSV(ctx, strings.NewReader(input), "contacts", ImportOptions{OnConflict: ConflictSkip})
+	if err != nil {
+		t.Fatalf("Failed to import with skip: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 row inserted with skip, got %d", n)
+	}
+
+	input = "email,name\nada@example.com,Ada King\n"
+	n, err = db.ImportCSV(ctx, strings.NewReader(input), "contacts", ImportOptions{
+		OnConflict:      ConflictUpsert,
+		ConflictColumns: []string{"email"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import with upsert: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 row upserted, got %d", n)
+	}
+
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM contacts WHERE email = 'ada@example.com'").Scan(&name); err != nil {
+		t.Fatalf("Failed to read upserted row: %v", err)
+	}
+	if name != "Ada King" {
+		t.Errorf("Expected upsert to update name, got %q", name)
+	}
+	if count, _ := db.Count(ctx, "contacts", ""); count != 2 {
+		t.Errorf("Expected 2 rows after upsert, got %d", count)
+	}
+}
@@ -0,0 +1,595 @@
+// Package database provides a libSQL interface supporting local and remote databases
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/knaka/go-sqlite3-fts5"
+	"github.com/mattn/go-sqlite3"
+	"github.com/parsel-email/lib-go/database/internal/sqlutil"
+	"github.com/tursodatabase/libsql-client-go/libsql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config holds database configuration
+type Config struct {
+	Path            string
+	AuthToken       string // libSQL auth token for remote connections
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	Pragmas         Pragmas
+	DefaultTxMode   TxMode        // Begin mode used by BeginTx and BeginTxOpts
+	SplitReadWrite  bool          // Use a read-only pool for queries and a single-connection write pool
+	Tracer          trace.Tracer  // Emits a span per statement when set
+	Logger          *slog.Logger  // Logs every statement via LogQuery when set
+	WarmupConns     int           // Connections to open up front, capped by MaxOpenConns
+	OpenTimeout     time.Duration // Bounds connection establishment in Open; zero waits indefinitely
+
+	// DefaultQueryTimeout bounds statements run through DB when the caller's
+	// context has no deadline of its own
+	DefaultQueryTimeout time.Duration
+
+	// Hooks are called around every Exec and Query run through DB
+	Hooks Hooks
+
+	QueryCacheSize int // Entries kept by CachedQueryRow; defaults to 1024
+
+	// SerializeWrites funnels ExecContext writes and write transactions
+	// through one dedicated connection, handed between callers over a
+	// channel, so writers queue in Go instead of failing with SQLITE_BUSY.
+	// Writes then wait for every write ahead of them, which adds latency
+	// under load, and a long transaction delays all other writes. Writes
+	// made through QueryContext, Session or raw connections are not
+	// serialized. Requires a local file database.
+	SerializeWrites bool
+
+	// ConnectRetries is how many more times the initial ping is attempted
+	// after a connection error, e.g. while a database container starts.
+	// The delay between attempts starts at ConnectRetryDelay, or 100ms if
+	// unset, and doubles each time.
+	ConnectRetries    int
+	ConnectRetryDelay time.Duration
+
+	// SecureDelete overwrites deleted content with zeros, so removed email
+	// bodies do not linger in free pages or the WAL. Every delete and
+	// update then writes the freed space as well, which slows bulk deletes.
+	// A secure_delete value in Pragmas, such as FAST, takes precedence.
+	// Local databases only.
+	SecureDelete bool
+
+	// SnapshotDir is where Snapshot keeps its copies; it defaults to the
+	// database path with a .snapshots suffix
+	SnapshotDir string
+
+	// Location is the time zone Time columns are converted to when scanned
+	// into structs; stored times are always UTC. It defaults to UTC.
+	Location *time.Location
+
+	// Proxy is a URL that remote HTTP requests are sent to in place of the
+	// database host, which is kept in the Host header. The libSQL driver
+	// always uses http.DefaultClient, so forward proxies and custom CA
+	// bundles are configured with HTTPS_PROXY and SSL_CERT_FILE instead.
+	Proxy string
+
+	// OnConnect runs once on every new physical connection before the pool
+	// uses it, for setup that must hold on each connection such as a
+	// pragma, an extension or a temp view. An error fails the connection.
+	OnConnect func(ctx context.Context, conn *sql.Conn) error
+}
+
+// DefaultConfig returns a default database configuration
+func DefaultConfig() Config {
+	return Config{
+		Path:            ":memory:", // Default to in-memory database
+		AuthToken:       "",         // Default to no auth token
+		MaxOpenConns:    5,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: time.Minute * 30,
+		Pragmas:         DefaultPragmas(),
+		DefaultTxMode:   TxDeferred,
+		OpenTimeout:     30 * time.Second,
+	}
+}
+
+// DB wraps a database connection pool
+type DB struct {
+	*sql.DB          // Write pool, or the only pool
+	reader   *sql.DB // Read pool when SplitReadWrite is enabled
+	cfg      Config
+	activeTx atomic.Int64   // Transactions begun but not yet finished
+	closing  atomic.Bool    // Set once Shutdown has been called
+	stats    queryStats     // Statement and error counts by operation
+	cache    *queryCache    // Results stored by CachedQueryRow
+	writer   chan *sql.Conn // Holds the write connection when SerializeWrites is enabled
+}
+
+// Open creates a new database connection, giving up after cfg.OpenTimeout
+func Open(cfg Config) (*DB, error) {
+	ctx, cancel := WithContext(context.Background(), cfg.OpenTimeout)
+	defer cancel()
+	return OpenContext(ctx, cfg)
+}
+
+// OpenContext creates a new database connection, aborting if ctx is done
+// before the connection is established
+func OpenContext(ctx context.Context, cfg Config) (*DB, error) {
+	if cfg.SplitReadWrite && (isRemote(cfg.Path) || isMemory(cfg.Path)) {
+		return nil, fmt.Errorf("separate read and write pools require a local file database")
+	}
+	if cfg.SerializeWrites && (isRemote(cfg.Path) || isMemory(cfg.Path)) {
+		return nil, fmt.Errorf("serialized writes require a local file database")
+	}
+
+	db, err := openPool(ctx, cfg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.SplitReadWrite {
+		if err := warmup(ctx, db, cfg.WarmupConns); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return newDB(ctx, cfg, db, nil)
+	}
+
+	// SQLite allows a single writer, so the write pool needs one connection.
+	// Serialized writes already hold one, and the rest serve other uses.
+	if !cfg.SerializeWrites {
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+	}
+
+	reader, err := openPool(ctx, cfg, true)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	for _, pool := range []*sql.DB{db, reader} {
+		if err := warmup(ctx, pool, cfg.WarmupConns); err != nil {
+			db.Close()
+			reader.Close()
+			return nil, err
+		}
+	}
+
+	return newDB(ctx, cfg, db, reader)
+}
+
+// newDB wraps the opened pools, reserving the write connection when writes
+// are serialized and warning about storage pragmas the database ignored
+func newDB(ctx context.Context, cfg Config, pool, reader *sql.DB) (*DB, error) {
+	db := &DB{DB: pool, reader: reader, cfg: cfg, cache: newQueryCache(cfg.QueryCacheSize)}
+	if cfg.SerializeWrites {
+		if err := db.startWriter(ctx); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if err := db.warnIgnoredPragmas(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// warmup opens up to n connections in parallel and returns them to the pool
+// idle, so early requests do not pay for connection setup. The count is
+// capped by the pool's maximum open connections.
+func warmup(ctx context.Context, db *sql.DB, n int) error {
+	if limit := db.Stats().MaxOpenConnections; limit > 0 && n > limit {
+		n = limit
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	conns := make([]*sql.Conn, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i], errs[i] = db.Conn(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	// Release every connection before reporting failures
+	for _, conn := range conns {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("warming up connections: %w", err)
+	}
+	return nil
+}
+
+// openPool opens and pings a connection pool for cfg
+func openPool(ctx context.Context, cfg Config, readOnly bool) (*sql.DB, error) {
+	var connector driver.Connector
+
+	if isRemote(cfg.Path) {
+		// For remote libSQL databases, use the libsql client connector
+		connOpts := []libsql.Option{}
+		if cfg.AuthToken != "" {
+			connOpts = append(connOpts, libsql.WithAuthToken(cfg.AuthToken))
+		}
+		if cfg.Proxy != "" {
+			connOpts = append(connOpts, libsql.WithProxy(cfg.Proxy))
+		}
+
+		remote, err := libsql.NewConnector(cfg.Path, connOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating libSQL connector: %w", err)
+		}
+		connector = &resetConnector{Connector: remote}
+	} else {
+		// For local file or in-memory database
+		pragmas := make(Pragmas, len(cfg.Pragmas))
+		for key, value := range cfg.Pragmas {
+			pragmas[key] = value
+		}
+
+		if _, ok := pragmas["secure_delete"]; cfg.SecureDelete && !ok {
+			pragmas["secure_delete"] = "ON"
+		}
+
+		// Each in-memory connection is private, so it never waits on a lock
+		if isMemory(cfg.Path) {
+			delete(pragmas, "busy_timeout")
+		}
+
+		params := pragmas
+		if readOnly {
+			params = make(Pragmas, len(pragmas)+1)
+			for key, value := range pragmas {
+				params[key] = value
+			}
+			params["mode"] = "ro"
+		}
+		dsn := formatDSN(cfg.Path, params)
+
+		// For local SQLite databases, use the sqlite3 connector with file: prefix
+		if dsn != ":memory:" && !strings.HasPrefix(dsn, "file:") {
+			dsn = "file:" + dsn
+		}
+
+		local, err := newConnector(dsn, pragmas)
+		if err != nil {
+			return nil, fmt.Errorf("opening database: %w", err)
+		}
+		connector = local
+	}
+
+	connector = &rowsCancelConnector{Connector: connector}
+	if cfg.OnConnect != nil {
+		connector = &hookConnector{Connector: connector, onConnect: cfg.OnConnect}
+	}
+	db := sql.OpenDB(connector)
+
+	// Configure connection pool
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// Test connection
+	if err := ping(ctx, db, cfg); err != nil {
+		db.Close() // Close the failed connection
+		return nil, fmt.Errorf("pinging database: %w", err)
+	}
+
+	return db, nil
+}
+
+// ping checks the pool can connect, retrying connection errors up to
+// cfg.ConnectRetries times with exponential backoff until ctx is done
+func ping(ctx context.Context, db *sql.DB, cfg Config) error {
+	delay := cfg.ConnectRetryDelay
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil || attempt >= cfg.ConnectRetries || !isConnectError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// isConnectError reports whether a ping failure may succeed on a later
+// attempt. Errors from the database itself, such as a file that is not a
+// database or a pragma it rejects, are configuration problems.
+func isConnectError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrError, sqlite3.ErrNotADB, sqlite3.ErrCorrupt, sqlite3.ErrMisuse, sqlite3.ErrRange, sqlite3.ErrAuth:
+			return false
+		}
+	}
+	return true
+}
+
+// ExecContext executes a statement on the write pool
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if db.closing.Load() {
+		return nil, ErrShutdown
+	}
+
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	var span trace.Span
+	if db.cfg.Tracer != nil {
+		ctx, span = db.startSpan(ctx, "db.Exec", query)
+		defer span.End()
+	}
+
+	ctx = db.beforeQuery(ctx, query, args)
+	start := time.Now()
+	res, err := db.exec(ctx, query, args...)
+	db.observe(ctx, span, opExec, query, start, err)
+	db.afterQuery(ctx, query, args, start, err)
+	if err != nil {
+		return nil, statementError(query, err)
+	}
+
+	if span != nil {
+		if n, err := res.RowsAffected(); err == nil {
+			span.SetAttributes(attribute.Int64("db.rows_affected", n))
+		}
+	}
+	return res, nil
+}
+
+// Exec executes a statement on the write pool
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext runs a query, using the read pool for read-only statements
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if db.closing.Load() {
+		return nil, ErrShutdown
+	}
+
+	// The rows stay bound to ctx after returning, so a default timeout is
+	// cancelled here only on failure and otherwise when the rows close
+	ctx, cancel := db.withRowsTimeout(ctx)
+
+	var span trace.Span
+	if db.cfg.Tracer != nil {
+		ctx, span = db.startSpan(ctx, "db.Query", query)
+		defer span.End()
+	}
+
+	ctx = db.beforeQuery(ctx, query, args)
+	start := time.Now()
+	rows, err := db.pool(query).QueryContext(ctx, query, args...)
+	db.observe(ctx, span, opQuery, query, start, err)
+	db.afterQuery(ctx, query, args, start, err)
+	if err != nil {
+		cancel()
+		return nil, statementError(query, err)
+	}
+	return rows, nil
+}
+
+// Query runs a query, using the read pool for read-only statements
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryWithCancel runs a query like QueryContext and also returns a cancel
+// function that interrupts it, for a search the user can abort. Cancelling
+// stops the running statement, closes the rows and returns the connection
+// to the pool; reading further rows then fails with context.Canceled. The
+// cancel function must be called once the rows are no longer needed.
+func (db *DB) QueryWithCancel(ctx context.Context, query string, args ...any) (*sql.Rows, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return rows, cancel, nil
+}
+
+// QueryRowContext runs a query returning at most one row, using the read
+// pool for read-only statements. Unlike ExecContext and QueryContext, its
+// errors are returned by Scan unwrapped, as sql.Row cannot carry the
+// statement.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if db.closing.Load() {
+		return errRow(ErrShutdown)
+	}
+
+	// As with QueryContext, the row is scanned after returning, so a default
+	// timeout is released when Scan closes it or the query fails
+	ctx, cancel := db.withRowsTimeout(ctx)
+
+	var span trace.Span
+	if db.cfg.Tracer != nil {
+		ctx, span = db.startSpan(ctx, "db.QueryRow", query)
+		defer span.End()
+	}
+
+	ctx = db.beforeQuery(ctx, query, args)
+	start := time.Now()
+	row := db.pool(query).QueryRowContext(ctx, query, args...)
+	db.observe(ctx, span, opQueryRow, query, start, row.Err())
+	db.afterQuery(ctx, query, args, start, row.Err())
+	if row.Err() != nil {
+		cancel()
+	}
+	return row
+}
+
+// maxErrorQueryLen is how much of a statement is included in its errors
+const maxErrorQueryLen = 200
+
+// statementError wraps err with the statement that failed, truncated and
+// with whitespace collapsed. Argument values are left out, as they may hold
+// email content or credentials.
+func statementError(query string, err error) error {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxErrorQueryLen {
+		n := maxErrorQueryLen
+		for n > 0 && !utf8.RuneStart(query[n]) {
+			n--
+		}
+		query = query[:n] + "..."
+	}
+	return fmt.Errorf("executing %q: %w", query, err)
+}
+
+// withQueryTimeout applies DefaultQueryTimeout to ctx if it has no deadline
+func (db *DB) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.cfg.DefaultQueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, db.cfg.DefaultQueryTimeout)
+}
+
+// observe records the outcome of a statement in the metrics, the span if
+// tracing is enabled, and the query log if a logger is configured
+func (db *DB) observe(ctx context.Context, span trace.Span, op operation, query string, start time.Time, err error) {
+	db.stats.record(op, err)
+	if span != nil && err != nil {
+		recordError(span, err)
+	}
+	if db.cfg.Logger != nil {
+		db.LogQuery(ctx, query, time.Since(start), err)
+	}
+}
+
+// QueryRow runs a query returning at most one row, using the read pool for
+// read-only statements
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// Close closes the database and any separate read pool
+func (db *DB) Close() error {
+	db.stopWriter()
+	err := db.DB.Close()
+	if db.reader != nil {
+		if rerr := db.reader.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// pool returns the pool a query should run on. Only statements that are
+// plainly reads go to the read pool; anything else, including
+// INSERT ... RETURNING and pragmas, uses the write pool.
+func (db *DB) pool(query string) *sql.DB {
+	if db.reader != nil && isReadQuery(query) {
+		return db.reader
+	}
+	return db.DB
+}
+
+// isReadQuery reports whether query starts with a read-only keyword. Common
+// table expressions count as reads unless they wrap a data-modifying statement.
+func isReadQuery(query string) bool {
+	fields := strings.Fields(strings.ToUpper(query))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "SELECT", "EXPLAIN", "VALUES":
+		return true
+	case "WITH":
+		for _, f := range fields[1:] {
+			switch f {
+			case "INSERT", "UPDATE", "DELETE", "REPLACE":
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// WithContext returns a context with timeout for database operations
+func WithContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// isMemory reports whether the path refers to an in-memory database
+func isMemory(path string) bool {
+	return sqlutil.IsMemory(path)
+}
+
+// filePath returns the file name of a local database path, stripping the
+// file: scheme, URI authority and query parameters from a DSN
+func filePath(path string) string {
+	if !strings.HasPrefix(path, "file:") {
+		return path
+	}
+	path, _, _ = strings.Cut(strings.TrimPrefix(path, "file:"), "?")
+	if rest, ok := strings.CutPrefix(path, "//"); ok {
+		// file://host/path names /path; SQLite only accepts an empty or
+		// localhost authority
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			path = rest[i:]
+		}
+	}
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	return path
+}
+
+// isRemote reports whether the path points at a remote libSQL server
+func isRemote(path string) bool {
+	for _, scheme := range []string{"libsql://", "https://", "http://", "wss://", "ws://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,609 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowQuery takes a noticeable amount of time to evaluate
+const slowQuery = `
+	WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000000)
+	SELECT COUNT(*) FROM c
+`
+
+func TestOpen(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE open_test (id INTEGER PRIMARY KEY, value TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO open_test (value) VALUES (?)", "test value"); err != nil {
+		t.Fatalf("Failed to insert data: %v", err)
+	}
+
+	var value string
+	if err := db.QueryRowContext(ctx, "SELECT value FROM open_test WHERE id = 1").Scan(&value); err != nil {
+		t.Fatalf("Failed to query data: %v", err)
+	}
+	if value != "test value" {
+		t.Errorf("Expected 'test value', got '%s'", value)
+	}
+}
+
+func TestBeginTx(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE tx_scaffold (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// A rolled back insert leaves no row
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO tx_scaffold DEFAULT VALUES"); err != nil {
+		t.Fatalf("Failed to insert in transaction: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+
+	// A committed insert is kept
+	tx, err = db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO tx_scaffold DEFAULT VALUES"); err != nil {
+		t.Fatalf("Failed to insert in transaction: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tx_scaffold").Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row, got %d", count)
+	}
+}
+
+func TestIsRemote(t *testing.T) {
+	tests := map[string]bool{
+		"libsql://db.turso.io":   true,
+		"https://db.turso.io":    true,
+		"ws://localhost:8080":    true,
+		":memory:":               false,
+		"parsel.db":              false,
+		"file:parsel.db?mode=ro": false,
+	}
+	for path, want := range tests {
+		if got := isRemote(path); got != want {
+			t.Errorf("isRemote(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestFormatDSN(t *testing.T) {
+	if dsn := formatDSN("parsel.db", nil); dsn != "parsel.db" {
+		t.Errorf("Expected bare path, got %s", dsn)
+	}
+	if dsn := formatDSN("parsel.db", Pragmas{"foreign_keys": "ON"}); dsn != "parsel.db?foreign_keys=ON" {
+		t.Errorf("Expected pragma parameter, got %s", dsn)
+	}
+}
+
+func TestValidateIdent(t *testing.T) {
+	for _, name := range []string{"emails", "_tmp", "Emails2"} {
+		if err := validateIdent(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "2emails", "emails; DROP TABLE x", "e-mails"} {
+		if err := validateIdent(name); err == nil {
+			t.Errorf("Expected %q to be invalid, got nil", name)
+		}
+	}
+}
+
+// measureWriteDuring times a write issued while slow reads occupy the pool
+func measureWriteDuring(t *testing.T, split bool) time.Duration {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "split.db")
+	cfg.MaxOpenConns = 2
+	cfg.SplitReadWrite = split
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE split_test (id INTEGER PRIMARY KEY, value TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var n int
+			if err := db.QueryRow(slowQuery).Scan(&n); err != nil {
+				t.Errorf("Slow read failed: %v", err)
+			}
+		}()
+	}
+
+	// Give the readers time to claim the pool
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := db.ExecContext(context.Background(), "INSERT INTO split_test (value) VALUES (?)", "write"); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	wg.Wait()
+	return elapsed
+}
+
+func TestSplitReadWrite(t *testing.T) {
+	shared := measureWriteDuring(t, false)
+	split := measureWriteDuring(t, true)
+
+	t.Logf("write latency under read load: shared=%v split=%v", shared, split)
+
+	if split >= shared {
+		t.Errorf("Expected split pools to reduce write latency, got shared=%v split=%v", shared, split)
+	}
+}
+
+func TestSplitReadWriteRouting(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "routing.db")
+	cfg.SplitReadWrite = true
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE routing_test (id INTEGER PRIMARY KEY, value TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Statements with side effects must reach the write pool
+	var id int64
+	err = db.QueryRowContext(ctx, "INSERT INTO routing_test (value) VALUES (?) RETURNING id", "a").Scan(&id)
+	if err != nil {
+		t.Fatalf("Failed to insert with RETURNING: %v", err)
+	}
+
+	var value string
+	err = db.QueryRowContext(ctx, "SELECT value FROM routing_test WHERE id = ?", id).Scan(&value)
+	if err != nil {
+		t.Fatalf("Failed to read from read pool: %v", err)
+	}
+	if value != "a" {
+		t.Errorf("Expected 'a', got '%s'", value)
+	}
+
+	// The read pool really is read-only
+	if _, err := db.reader.ExecContext(ctx, "INSERT INTO routing_test (value) VALUES ('b')"); err == nil {
+		t.Error("Expected write on read pool to fail")
+	}
+
+	// Read-only transactions leave the write connection free
+	tx, err := db.BeginTxOpts(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to begin read-only transaction: %v", err)
+	}
+	writeCtx, writeCancel := context.WithTimeout(ctx, time.Second)
+	_, err = db.ExecContext(writeCtx, "INSERT INTO routing_test (value) VALUES ('c')")
+	writeCancel()
+	if err != nil {
+		t.Errorf("Expected write during read-only transaction to succeed, got %v", err)
+	}
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM routing_test").Scan(new(int)); err != nil {
+		t.Errorf("Failed to read in read-only transaction: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("Failed to end read-only transaction: %v", err)
+	}
+
+	// In-memory databases cannot be split
+	memCfg := DefaultConfig()
+	memCfg.SplitReadWrite = true
+	if _, err := Open(memCfg); err == nil {
+		t.Error("Expected error splitting an in-memory database")
+	}
+}
+
+func TestWarmupConns(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "warmup.db")
+	cfg.WarmupConns = 3
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if open := db.Stats().OpenConnections; open != 3 {
+		t.Errorf("Expected 3 open connections after warm-up, got %d", open)
+	}
+
+	// Warm-up never exceeds the pool limit
+	cfg.Path = filepath.Join(t.TempDir(), "capped.db")
+	cfg.MaxOpenConns = 2
+	cfg.WarmupConns = 10
+
+	capped, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer capped.Close()
+
+	if open := capped.Stats().OpenConnections; open != 2 {
+		t.Errorf("Expected warm-up capped at 2 connections, got %d", open)
+	}
+}
+
+func TestOpenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "cancelled.db")
+
+	start := time.Now()
+	db, err := OpenContext(ctx, cfg)
+	if err == nil {
+		db.Close()
+		t.Fatal("Expected error for cancelled context, got nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected a prompt error, took %v", elapsed)
+	}
+}
+
+func TestConnectRetries(t *testing.T) {
+	// The database directory only appears after the first attempt fails,
+	// like a volume mounted after the service starts
+	dir := filepath.Join(t.TempDir(), "late")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.Mkdir(dir, 0o755)
+	}()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(dir, "retry.db")
+	cfg.ConnectRetries = 10
+	cfg.ConnectRetryDelay = 10 * time.Millisecond
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database after retries: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Errorf("Failed to use database: %v", err)
+	}
+
+	// Without retries the same situation fails immediately
+	cfg.Path = filepath.Join(t.TempDir(), "missing", "retry.db")
+	cfg.ConnectRetries = 0
+	if db, err := Open(cfg); err == nil {
+		db.Close()
+		t.Error("Expected error without retries, got nil")
+	}
+}
+
+func TestConnectRetriesConfigError(t *testing.T) {
+	// A file that is not a database will never start working
+	path := filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(path, []byte("this is not an SQLite database file, not even close"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Path = path
+	cfg.ConnectRetries = 5
+	cfg.ConnectRetryDelay = time.Second
+
+	start := time.Now()
+	db, err := Open(cfg)
+	if err == nil {
+		db.Close()
+		t.Fatal("Expected error opening a non-database file, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected configuration error without retrying, took %v", elapsed)
+	}
+}
+
+// verySlowQuery runs far longer than any test timeout
+const verySlowQuery = `
+	WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000000000)
+	SELECT COUNT(*) FROM c
+`
+
+func TestDefaultQueryTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DefaultQueryTimeout = 50 * time.Millisecond
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// A context without a deadline gets the default timeout
+	start := time.Now()
+	var n int
+	err = db.QueryRowContext(context.Background(), verySlowQuery).Scan(&n)
+	if err == nil {
+		t.Fatal("Expected slow query to time out, got nil")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected query to stop near the timeout, took %v", elapsed)
+	}
+
+	if _, err := db.ExecContext(context.Background(), "CREATE TABLE t AS "+verySlowQuery); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected exec to time out with context.DeadlineExceeded, got %v", err)
+	}
+
+	// Fast queries are unaffected, and rows outlive the call that opened them
+	rows, err := db.QueryContext(context.Background(), "SELECT 1 UNION ALL SELECT 2")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	rows.Close()
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got %d", count)
+	}
+
+	// A caller's own deadline takes precedence over the default
+	ctx, cancel := WithContext(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.QueryRowContext(ctx, slowQuery).Scan(&n); err != nil {
+		t.Errorf("Expected caller deadline to override the default, got %v", err)
+	}
+}
+
+func TestDefaultQueryTimeoutReleased(t *testing.T) {
+	var queryCtx context.Context
+
+	cfg := DefaultConfig()
+	cfg.DefaultQueryTimeout = time.Hour
+	cfg.Hooks.BeforeQuery = func(ctx context.Context, query string, args []any) context.Context {
+		queryCtx = ctx
+		return ctx
+	}
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// The timeout lasts while the rows are read and ends when they close
+	rows, err := db.QueryContext(context.Background(), "SELECT 1 UNION ALL SELECT 2")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if !rows.Next() {
+		t.Fatalf("Expected a row: %v", rows.Err())
+	}
+	if queryCtx.Err() != nil {
+		t.Errorf("Expected the timeout to last until the rows close, got %v", queryCtx.Err())
+	}
+	rows.Close()
+	if !errors.Is(queryCtx.Err(), context.Canceled) {
+		t.Errorf("Expected closing the rows to release the timeout, got %v", queryCtx.Err())
+	}
+
+	// Scanning a single row releases it as well
+	var n int
+	if err := db.QueryRowContext(context.Background(), "SELECT 1").Scan(&n); err != nil {
+		t.Fatalf("Failed to query row: %v", err)
+	}
+	if !errors.Is(queryCtx.Err(), context.Canceled) {
+		t.Errorf("Expected Scan to release the timeout, got %v", queryCtx.Err())
+	}
+}
+
+func TestRemoteProxy(t *testing.T) {
+	type request struct {
+		host, auth string
+	}
+	requests := make(chan request, 10)
+
+	// Stand-in for a proxy that records what it receives and refuses it
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- request{host: r.Host, auth: r.Header.Get("Authorization")}
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer proxy.Close()
+
+	cfg := DefaultConfig()
+	cfg.Path = "libsql://emails.example.invalid"
+	cfg.AuthToken = "secret"
+	cfg.Proxy = proxy.URL
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("SELECT 1"); err == nil {
+		t.Fatal("Expected error from refusing proxy, got nil")
+	}
+
+	select {
+	case r := <-requests:
+		if r.host != "emails.example.invalid" {
+			t.Errorf("Expected Host header emails.example.invalid, got %q", r.host)
+		}
+		if r.auth != "Bearer secret" {
+			t.Errorf("Expected bearer token to be forwarded, got %q", r.auth)
+		}
+	default:
+		t.Fatal("Expected the request to go through the proxy")
+	}
+}
+
+func TestStatementErrors(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// The statement is included, but not the argument values
+	_, err = db.ExecContext(ctx, "INSERT INTO missing (subject)\n\tVALUES (?)", "secret subject")
+	if err == nil {
+		t.Fatal("Expected error inserting into a missing table, got nil")
+	}
+	if !strings.Contains(err.Error(), `"INSERT INTO missing (subject) VALUES (?)"`) {
+		t.Errorf("Expected error to contain the statement, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret subject") {
+		t.Errorf("Expected error to omit argument values, got %v", err)
+	}
+
+	// Long statements are truncated
+	long := "SELECT id FROM emails WHERE subject IN ('" + strings.Repeat("x', '", 100) + "') AND missing_column = 1"
+	_, err = db.QueryContext(ctx, long)
+	if err == nil {
+		t.Fatal("Expected error querying a missing column, got nil")
+	}
+	if strings.Contains(err.Error(), "missing_column = 1") || !strings.Contains(err.Error(), `..."`) {
+		t.Errorf("Expected a truncated statement, got %v", err)
+	}
+
+	// Transactions wrap their errors the same way
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO emails (subject) VALUES (?)", nil)
+	if err == nil || !strings.Contains(err.Error(), "INSERT INTO emails") {
+		t.Errorf("Expected error to contain the statement, got %v", err)
+	}
+}
+
+func TestQueryWithCancel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxOpenConns = 1
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Counting this far takes far longer than the test allows
+	rows, stop, err := db.QueryWithCancel(ctx, `
+		WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 10000000000)
+		SELECT COUNT(*) FROM c
+	`)
+	if err != nil {
+		t.Fatalf("Failed to start query: %v", err)
+	}
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() {
+		for rows.Next() {
+		}
+		done <- rows.Err()
+	}()
+
+	// Abort the query mid-flight
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	stop()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected error from cancelled query, got nil")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Query was not interrupted")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected prompt interruption, took %v", elapsed)
+	}
+
+	// The only connection must be free for the next query
+	var one int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		t.Fatalf("Failed to query after cancel: %v", err)
+	}
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("Expected no connections in use, got %d", inUse)
+	}
+}
@@ -0,0 +1,43 @@
+// Package dbtest provides helpers for tests that need a database
+package dbtest
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/parsel-email/lib-go/database"
+	"github.com/parsel-email/lib-go/migrations"
+)
+
+// New opens a fresh database in a temporary directory and closes it when
+// the test finishes. A file is used rather than :memory: so that every
+// pooled connection sees the same data.
+func New(t testing.TB) *database.DB {
+	t.Helper()
+
+	cfg := database.DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// NewWithMigrations opens a fresh database like New and applies all
+// migrations from source before returning it
+func NewWithMigrations(t testing.TB, source fs.FS) *database.DB {
+	t.Helper()
+
+	db := New(t)
+	if _, err := migrations.Up(context.Background(), db.DB, source); err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+
+	return db
+}
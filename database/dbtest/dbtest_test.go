@@ -0,0 +1,43 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNew(t *testing.T) {
+	db := New(t)
+
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Every test gets its own database
+	other := New(t)
+	var count int
+	err := other.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'items'").Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query schema: %v", err)
+	}
+	if count != 0 {
+		t.Error("Expected a separate database per call")
+	}
+}
+
+func TestNewWithMigrations(t *testing.T) {
+	source := fstest.MapFS{
+		"1_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);")},
+		"1_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+
+	db := NewWithMigrations(t, source)
+
+	exists, err := db.Exists(context.Background(), "users", "")
+	if err != nil {
+		t.Fatalf("Failed to query migrated table: %v", err)
+	}
+	if exists {
+		t.Error("Expected migrated users table to be empty")
+	}
+}
@@ -0,0 +1,44 @@
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/parsel-email/lib-go/database"
+)
+
+// errRollback makes WithTransaction roll back the RunInRollback transaction
+var errRollback = errors.New("dbtest: rolled back")
+
+// RunInRollback runs fn inside a transaction that is always rolled back, so
+// tests sharing a database do not see each other's writes. The transaction
+// is begun with db.WithTransaction and travels in the context passed to fn,
+// so RunInRollback and WithTransaction calls made with that context, at any
+// depth, use savepoints on it instead of beginning a new transaction, which
+// SQLite's single writer would block.
+//
+// Code under test must accept the context, or the *database.Transaction,
+// for its writes to be undone. Parallel tests sharing db each hold the
+// writer for the length of fn, so they run one after another.
+func RunInRollback(t testing.TB, ctx context.Context, db *database.DB, fn func(ctx context.Context, tx *database.Transaction)) {
+	t.Helper()
+
+	err := db.WithTransaction(ctx, func(ctx context.Context, tx *database.Transaction) error {
+		// t.Fatal in fn exits the goroutine without returning, which
+		// WithTransaction would take as success and commit
+		returned := false
+		defer func() {
+			if !returned {
+				tx.Rollback()
+			}
+		}()
+
+		fn(ctx, tx)
+		returned = true
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Errorf("Failed to roll back transaction: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/parsel-email/lib-go/database"
+)
+
+// countItems returns the number of rows in items as seen by tx
+func countItems(t *testing.T, tx *database.Transaction) int {
+	t.Helper()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM items").Scan(&count); err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	return count
+}
+
+func TestRunInRollback(t *testing.T) {
+	db := New(t)
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	ctx := context.Background()
+	RunInRollback(t, ctx, db, func(ctx context.Context, tx *database.Transaction) {
+		if _, err := tx.Exec("INSERT INTO items (name) VALUES ('outer')"); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+
+		// A nested call sees the outer writes and undoes only its own
+		RunInRollback(t, ctx, db, func(ctx context.Context, inner *database.Transaction) {
+			if _, err := inner.Exec("INSERT INTO items (name) VALUES ('inner')"); err != nil {
+				t.Fatalf("Failed to insert: %v", err)
+			}
+			if n := countItems(t, inner); n != 2 {
+				t.Errorf("Expected 2 items inside nested call, got %d", n)
+			}
+		})
+
+		if n := countItems(t, tx); n != 1 {
+			t.Errorf("Expected nested insert to be rolled back, got %d items", n)
+		}
+
+		// Code under test using WithTransaction joins the transaction
+		err := db.WithTransaction(ctx, func(ctx context.Context, tx *database.Transaction) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO items (name) VALUES ('service')")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to run nested WithTransaction: %v", err)
+		}
+		if n := countItems(t, tx); n != 2 {
+			t.Errorf("Expected nested WithTransaction insert to be visible, got %d items", n)
+		}
+	})
+
+	// Nothing is left behind
+	count, err := db.Count(ctx, "items", "")
+	if err != nil {
+		t.Fatalf("Failed to count items: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected all writes to be rolled back, got %d items", count)
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxParams is SQLite's default limit on bound parameters per statement
+const maxParams = 999
+
+// ErrTooManyParams is returned when a statement would exceed maxParams
+var ErrTooManyParams = errors.New("too many query parameters")
+
+// ExpandIn rewrites a query so that each slice argument bound to a single ?
+// placeholder is expanded into one placeholder per element, flattening the
+// arguments to match. Byte slices are treated as scalar BLOB values.
+//
+//	ExpandIn("SELECT * FROM emails WHERE id IN (?)", []int{1, 2, 3})
+//	// "SELECT * FROM emails WHERE id IN (?,?,?)", []any{1, 2, 3}
+func ExpandIn(query string, args ...any) (string, []any, error) {
+	var b strings.Builder
+	b.Grow(len(query))
+	expanded := make([]any, 0, len(args))
+
+	argIdx := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		// Skip over string literals and quoted identifiers
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+			continue
+		}
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+			b.WriteByte(c)
+			continue
+		}
+
+		if c != '?' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if argIdx >= len(args) {
+			return "", nil, fmt.Errorf("query has more placeholders than arguments (%d)", len(args))
+		}
+		arg := args[argIdx]
+		argIdx++
+
+		v := reflect.ValueOf(arg)
+		if arg == nil || v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+			b.WriteByte('?')
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		if v.Len() == 0 {
+			return "", nil, fmt.Errorf("empty slice for placeholder %d", argIdx)
+		}
+		for j := 0; j < v.Len(); j++ {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('?')
+			expanded = append(expanded, v.Index(j).Interface())
+		}
+	}
+
+	if argIdx != len(args) {
+		return "", nil, fmt.Errorf("query has %d placeholders but %d arguments", argIdx, len(args))
+	}
+	if len(expanded) > maxParams {
+		return "", nil, fmt.Errorf("%w: %d exceeds limit of %d", ErrTooManyParams, len(expanded), maxParams)
+	}
+
+	return b.String(), expanded, nil
+}
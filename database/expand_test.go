@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpandIn(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		args      []any
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			name:      "single slice",
+			query:     "SELECT * FROM emails WHERE id IN (?)",
+			args:      []any{[]int{1, 2, 3}},
+			wantQuery: "SELECT * FROM emails WHERE id IN (?,?,?)",
+			wantArgs:  []any{1, 2, 3},
+		},
+		{
+			name:      "mixed scalar and slices",
+			query:     "SELECT * FROM emails WHERE owner = ? AND id IN (?) AND label IN (?) AND size > ?",
+			args:      []any{"alice", []int64{7, 8}, []string{"inbox"}, 100},
+			wantQuery: "SELECT * FROM emails WHERE owner = ? AND id IN (?,?) AND label IN (?) AND size > ?",
+			wantArgs:  []any{"alice", int64(7), int64(8), "inbox", 100},
+		},
+		{
+			name:      "byte slice is scalar",
+			query:     "SELECT * FROM blobs WHERE data = ? AND id IN (?)",
+			args:      []any{[]byte{1, 2}, []int{4, 5}},
+			wantQuery: "SELECT * FROM blobs WHERE data = ? AND id IN (?,?)",
+			wantArgs:  []any{[]byte{1, 2}, 4, 5},
+		},
+		{
+			name:      "placeholder in literal is ignored",
+			query:     "SELECT * FROM emails WHERE subject = 'why?' AND id IN (?)",
+			args:      []any{[]int{1, 2}},
+			wantQuery: "SELECT * FROM emails WHERE subject = 'why?' AND id IN (?,?)",
+			wantArgs:  []any{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := ExpandIn(tt.query, tt.args...)
+			if err != nil {
+				t.Fatalf("ExpandIn returned error: %v", err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("Expected query %q, got %q", tt.wantQuery, query)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestExpandInErrors(t *testing.T) {
+	if _, _, err := ExpandIn("SELECT * FROM t WHERE id IN (?)", []int{}); err == nil {
+		t.Error("Expected error for empty slice, got nil")
+	}
+
+	if _, _, err := ExpandIn("SELECT * FROM t WHERE a = ? AND b = ?", 1); err == nil {
+		t.Error("Expected error for missing argument, got nil")
+	}
+
+	if _, _, err := ExpandIn("SELECT * FROM t WHERE a = ?", 1, 2); err == nil {
+		t.Error("Expected error for extra argument, got nil")
+	}
+
+	ids := make([]int, maxParams+1)
+	_, _, err := ExpandIn("SELECT * FROM t WHERE id IN (?)", ids)
+	if !errors.Is(err, ErrTooManyParams) {
+		t.Errorf("Expected ErrTooManyParams, got: %v", err)
+	}
+}
+
+func TestExpandInQuery(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	setupIter(t, db, 10)
+
+	query, args, err := ExpandIn("SELECT COUNT(*) FROM iter_test WHERE id IN (?) AND value != ?",
+		[]int{1, 2, 3, 4}, "value 0")
+	if err != nil {
+		t.Fatalf("ExpandIn returned error: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows, got %d", count)
+	}
+}
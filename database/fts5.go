@@ -0,0 +1,296 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrFTS5Unavailable is returned by the FTS5 helpers when the SQLite build
+// lacks the fts5 module. The local driver imports go-sqlite3-fts5, as does
+// the sqlite3 package, and the libsql package and libSQL servers include it,
+// so this only happens with custom minimal SQLite builds.
+var ErrFTS5Unavailable = errors.New("FTS5 is not available in this SQLite build")
+
+// HasFTS5 reports whether the fts5 module is available
+func (db *DB) HasFTS5(ctx context.Context) bool {
+	ok, err := db.HasModule(ctx, "fts5")
+	return err == nil && ok
+}
+
+// fts5Error replaces err with ErrFTS5Unavailable when the failure is caused
+// by a missing fts5 module
+func fts5Error(err error) error {
+	if strings.Contains(err.Error(), "no such module: fts5") {
+		return ErrFTS5Unavailable
+	}
+	return err
+}
+
+// tokenizerOptions lists the options accepted by each supported FTS5 tokenizer
+var tokenizerOptions = map[string][]string{
+	"unicode61": {"remove_diacritics", "tokenchars", "separators", "categories"},
+	"porter":    {"remove_diacritics", "tokenchars", "separators", "categories"},
+	"ascii":     {"tokenchars", "separators"},
+	"trigram":   {"case_sensitive", "remove_diacritics"},
+}
+
+// Tokenizer configures the tokenizer used by an FTS5 table
+type Tokenizer struct {
+	Name    string            // unicode61, porter, ascii, or trigram
+	Options map[string]string // e.g. remove_diacritics, tokenchars, separators
+}
+
+// FTS5Options configures the creation of an FTS5 virtual table
+type FTS5Options struct {
+	Columns      []string
+	Content      string // External content table, if any
+	ContentRowID string // Rowid column of the external content table
+	Tokenizer    Tokenizer
+}
+
+// Validate checks the tokenizer name and options
+func (t Tokenizer) Validate() error {
+	allowed, ok := tokenizerOptions[t.Name]
+	if !ok {
+		return fmt.Errorf("unknown FTS5 tokenizer %q", t.Name)
+	}
+
+	for key, value := range t.Options {
+		known := false
+		for _, a := range allowed {
+			if a == key {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("option %q not supported by tokenizer %s", key, t.Name)
+		}
+
+		switch key {
+		case "remove_diacritics":
+			if value != "0" && value != "1" && value != "2" {
+				return fmt.Errorf("invalid remove_diacritics value %q", value)
+			}
+		case "case_sensitive":
+			if value != "0" && value != "1" {
+				return fmt.Errorf("invalid case_sensitive value %q", value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// String formats the tokenizer as an FTS5 tokenize argument
+func (t Tokenizer) String() string {
+	parts := []string{t.Name}
+
+	// The porter tokenizer wraps unicode61, which receives the options
+	if t.Name == "porter" {
+		parts = append(parts, "unicode61")
+	}
+
+	// Sort option keys so the generated SQL is deterministic
+	keys := make([]string, 0, len(t.Options))
+	for key := range t.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		parts = append(parts, key, quoteLiteral(t.Options[key]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// CreateFTS5 creates an FTS5 virtual table with the configured tokenizer
+func (db *DB) CreateFTS5(ctx context.Context, table string, opts FTS5Options) error {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return err
+	}
+	if len(opts.Columns) == 0 {
+		return fmt.Errorf("at least one column is required")
+	}
+
+	args := make([]string, 0, len(opts.Columns)+3)
+	for _, col := range opts.Columns {
+		quotedCol, err := quoteIdent(col)
+		if err != nil {
+			return err
+		}
+		args = append(args, quotedCol)
+	}
+
+	if opts.Content != "" {
+		if err := validateIdent(opts.Content); err != nil {
+			return err
+		}
+		args = append(args, "content="+quoteLiteral(opts.Content))
+	}
+	if opts.ContentRowID != "" {
+		if err := validateIdent(opts.ContentRowID); err != nil {
+			return err
+		}
+		args = append(args, "content_rowid="+quoteLiteral(opts.ContentRowID))
+	}
+
+	// Reject unknown tokenizers before issuing any SQL
+	if opts.Tokenizer.Name != "" {
+		if err := opts.Tokenizer.Validate(); err != nil {
+			return err
+		}
+		args = append(args, "tokenize="+quoteLiteral(opts.Tokenizer.String()))
+	}
+
+	stmt := fmt.Sprintf("CREATE VIRTUAL TABLE %s USING fts5(%s)", quoted, strings.Join(args, ", "))
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("creating FTS5 table %s: %w", table, fts5Error(err))
+	}
+
+	return nil
+}
+
+// SearchOptions configures an FTS5 search
+type SearchOptions struct {
+	Column    string // Column to excerpt; empty disables snippets
+	Highlight bool   // Return the full column via highlight() instead of snippet()
+	StartMark string // Inserted before each matched term
+	EndMark   string // Inserted after each matched term
+	Ellipsis  string // Marks text trimmed from a snippet
+	Tokens    int    // Maximum tokens in a snippet (1-64)
+	Limit     int
+	Offset    int
+}
+
+// SearchResult is a single FTS5 match
+type SearchResult struct {
+	RowID   int64
+	Score   float64 // bm25 score; lower values are more relevant
+	Snippet string  // Excerpt of the configured column, if requested
+}
+
+// SearchFTS5 runs a MATCH query against an FTS5 table ordered by bm25 relevance
+func (db *DB) SearchFTS5(ctx context.Context, table, query string, opts SearchOptions) ([]SearchResult, error) {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the excerpt expression if a column was requested
+	excerpt := "''"
+	var args []any
+	if opts.Column != "" {
+		var col int
+		err := db.QueryRowContext(ctx,
+			"SELECT cid FROM pragma_table_info(?) WHERE name = ?", table, opts.Column).Scan(&col)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("column %q not found in %s", opts.Column, table)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("resolving column %q: %w", opts.Column, err)
+		}
+
+		if opts.Highlight {
+			excerpt = fmt.Sprintf("highlight(%s, ?, ?, ?)", quoted)
+			args = append(args, col, opts.StartMark, opts.EndMark)
+		} else {
+			tokens := opts.Tokens
+			if tokens <= 0 || tokens > 64 {
+				tokens = 16
+			}
+			ellipsis := opts.Ellipsis
+			if ellipsis == "" {
+				ellipsis = "..."
+			}
+			excerpt = fmt.Sprintf("snippet(%s, ?, ?, ?, ?, ?)", quoted)
+			args = append(args, col, opts.StartMark, opts.EndMark, ellipsis, tokens)
+		}
+	}
+
+	// SQLite treats a negative LIMIT as unbounded
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, query, limit, opts.Offset)
+
+	stmt := fmt.Sprintf(
+		"SELECT rowid, bm25(%[1]s), %[2]s FROM %[1]s WHERE %[1]s MATCH ? ORDER BY bm25(%[1]s) LIMIT ? OFFSET ?",
+		quoted, excerpt)
+
+	rows, err := db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("searching %s: %w", table, fts5Error(err))
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.RowID, &r.Score, &r.Snippet); err != nil {
+			return nil, fmt.Errorf("scanning search result: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// RebuildFTS5 rebuilds the full-text index of an FTS5 table from its content
+func (db *DB) RebuildFTS5(ctx context.Context, table string) error {
+	return db.fts5Command(ctx, table, "rebuild")
+}
+
+// OptimizeFTS5 merges the b-trees of an FTS5 index into a single structure
+func (db *DB) OptimizeFTS5(ctx context.Context, table string) error {
+	return db.fts5Command(ctx, table, "optimize")
+}
+
+// fts5Command issues an FTS5 special INSERT command against table
+func (db *DB) fts5Command(ctx context.Context, table, command string) error {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return err
+	}
+
+	ok, err := db.isFTS5Table(ctx, table)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s is not an FTS5 table", table)
+	}
+
+	stmt := fmt.Sprintf("INSERT INTO %[1]s(%[1]s) VALUES (?)", quoted)
+	if _, err := db.ExecContext(ctx, stmt, command); err != nil {
+		return fmt.Errorf("running FTS5 %s on %s: %w", command, table, fts5Error(err))
+	}
+
+	return nil
+}
+
+// isFTS5Table reports whether table is an FTS5 virtual table
+func (db *DB) isFTS5Table(ctx context.Context, table string) (bool, error) {
+	var ddl string
+	err := db.QueryRowContext(ctx,
+		"SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&ddl)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("looking up table %s: %w", table, err)
+	}
+
+	ddl = strings.ToLower(ddl)
+	return strings.HasPrefix(ddl, "create virtual table") && strings.Contains(ddl, "using fts5"), nil
+}
@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupFTS5 creates a documents table with an external-content FTS5 index
+func setupFTS5(t *testing.T, db *DB, ctx context.Context) {
+	t.Helper()
+
+	statements := []string{
+		`CREATE TABLE documents (
+			id INTEGER PRIMARY KEY,
+			title TEXT NOT NULL,
+			content TEXT
+		)`,
+		`CREATE VIRTUAL TABLE documents_fts USING fts5(
+			title, content, content='documents', content_rowid='id'
+		)`,
+		`CREATE TRIGGER documents_ai AFTER INSERT ON documents BEGIN
+			INSERT INTO documents_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
+		END`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up FTS5 schema: %v", err)
+		}
+	}
+
+	docs := []struct {
+		title   string
+		content string
+	}{
+		{"Golang Database", "How to use database/sql package in Go"},
+		{"SQLite in Go", "Using SQLite with Go is simple and efficient"},
+		{"JSON in SQLite", "SQLite supports JSON data format for flexible storage"},
+	}
+	for _, doc := range docs {
+		_, err := db.ExecContext(ctx, "INSERT INTO documents (title, content) VALUES (?, ?)",
+			doc.title, doc.content)
+		if err != nil {
+			t.Fatalf("Failed to insert document: %v", err)
+		}
+	}
+}
+
+func TestSearchFTS5(t *testing.T) {
+	// Use in-memory database for testing
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	setupFTS5(t, db, ctx)
+
+	results, err := db.SearchFTS5(ctx, "documents_fts", "sqlite", SearchOptions{
+		Column:    "content",
+		StartMark: "[",
+		EndMark:   "]",
+	})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 search results, got %d", len(results))
+	}
+
+	// Results should be ordered by relevance
+	if results[0].Score > results[1].Score {
+		t.Errorf("Expected results ordered by bm25, got scores %f, %f", results[0].Score, results[1].Score)
+	}
+
+	for _, r := range results {
+		if !strings.Contains(r.Snippet, "[SQLite]") {
+			t.Errorf("Expected snippet to wrap matched term, got %q", r.Snippet)
+		}
+	}
+
+	// Test highlight and pagination
+	results, err = db.SearchFTS5(ctx, "documents_fts", "sqlite", SearchOptions{
+		Column:    "title",
+		Highlight: true,
+		StartMark: "<b>",
+		EndMark:   "</b>",
+		Limit:     1,
+		Offset:    1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to search with highlight: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 search result, got %d", len(results))
+	}
+
+	if !strings.Contains(results[0].Snippet, "<b>SQLite</b>") {
+		t.Errorf("Expected highlighted title, got %q", results[0].Snippet)
+	}
+}
+
+func TestSearchFTS5InvalidTable(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.SearchFTS5(context.Background(), "docs; DROP TABLE x", "sqlite", SearchOptions{})
+	if err == nil {
+		t.Error("Expected error for invalid table name, got nil")
+	}
+}
+
+func TestCreateFTS5Tokenizers(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Create one table per tokenizer
+	tables := map[string]Tokenizer{
+		"plain_fts":   {Name: "unicode61", Options: map[string]string{"remove_diacritics": "2"}},
+		"stemmed_fts": {Name: "porter", Options: map[string]string{"tokenchars": "-_"}},
+	}
+	for table, tokenizer := range tables {
+		err := db.CreateFTS5(ctx, table, FTS5Options{
+			Columns:   []string{"body"},
+			Tokenizer: tokenizer,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", table, err)
+		}
+
+		_, err = db.ExecContext(ctx, "INSERT INTO "+table+" (body) VALUES (?)", "I was running late")
+		if err != nil {
+			t.Fatalf("Failed to insert into %s: %v", table, err)
+		}
+	}
+
+	// Only the porter tokenizer should match the stem
+	plain, err := db.SearchFTS5(ctx, "plain_fts", "run", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search plain_fts: %v", err)
+	}
+	if len(plain) != 0 {
+		t.Errorf("Expected unicode61 not to match 'run', got %d results", len(plain))
+	}
+
+	stemmed, err := db.SearchFTS5(ctx, "stemmed_fts", "run", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search stemmed_fts: %v", err)
+	}
+	if len(stemmed) != 1 {
+		t.Errorf("Expected porter to match 'run', got %d results", len(stemmed))
+	}
+}
+
+func TestCreateFTS5InvalidTokenizer(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tests := []Tokenizer{
+		{Name: "snowball"},
+		{Name: "ascii", Options: map[string]string{"remove_diacritics": "1"}},
+		{Name: "unicode61", Options: map[string]string{"remove_diacritics": "3"}},
+	}
+	for _, tokenizer := range tests {
+		err := db.CreateFTS5(context.Background(), "bad_fts", FTS5Options{
+			Columns:   []string{"body"},
+			Tokenizer: tokenizer,
+		})
+		if err == nil {
+			t.Errorf("Expected error for tokenizer %+v, got nil", tokenizer)
+		}
+	}
+
+	// Nothing should have been created
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'bad_fts'").Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query schema: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no table to be created, got %d", count)
+	}
+}
+
+func TestRebuildFTS5(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	setupFTS5(t, db, ctx)
+
+	// Bypass the trigger so the index no longer matches the content table
+	if _, err := db.ExecContext(ctx, "DROP TRIGGER documents_ai"); err != nil {
+		t.Fatalf("Failed to drop trigger: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO documents (title, content) VALUES (?, ?)",
+		"Vector search", "Embeddings stored next to documents")
+	if err != nil {
+		t.Fatalf("Failed to insert document: %v", err)
+	}
+
+	results, err := db.SearchFTS5(ctx, "documents_fts", "embeddings", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("Expected stale index to miss new document, got %d results", len(results))
+	}
+
+	if err := db.RebuildFTS5(ctx, "documents_fts"); err != nil {
+		t.Fatalf("Failed to rebuild index: %v", err)
+	}
+
+	results, err = db.SearchFTS5(ctx, "documents_fts", "embeddings", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].RowID != 4 {
+		t.Errorf("Expected rebuilt index to find document 4, got %+v", results)
+	}
+
+	if err := db.OptimizeFTS5(ctx, "documents_fts"); err != nil {
+		t.Fatalf("Failed to optimize index: %v", err)
+	}
+
+	// Regular tables are rejected
+	if err := db.RebuildFTS5(ctx, "documents"); err == nil {
+		t.Error("Expected error rebuilding a non-FTS5 table, got nil")
+	}
+}
+
+func TestFTS5ReservedNames(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Table and column names that are SQL keywords
+	if err := db.CreateFTS5(ctx, "order", FTS5Options{Columns: []string{"group", "select"}}); err != nil {
+		t.Fatalf("Failed to create FTS5 table: %v", err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO "order" ("group", "select") VALUES (?, ?)`,
+		"Quarterly report", "Revenue grew in every region")
+	if err != nil {
+		t.Fatalf("Failed to insert document: %v", err)
+	}
+
+	results, err := db.SearchFTS5(ctx, "order", "revenue", SearchOptions{Column: "select", Highlight: true, StartMark: "[", EndMark: "]"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].Snippet != "[Revenue] grew in every region" {
+		t.Errorf("Expected one highlighted match, got %+v", results)
+	}
+
+	if err := db.RebuildFTS5(ctx, "order"); err != nil {
+		t.Fatalf("Failed to rebuild index: %v", err)
+	}
+	if err := db.OptimizeFTS5(ctx, "order"); err != nil {
+		t.Fatalf("Failed to optimize index: %v", err)
+	}
+}
+
+func TestHasFTS5(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The default build links go-sqlite3-fts5
+	if !db.HasFTS5(ctx) {
+		t.Fatal("Expected FTS5 to be available")
+	}
+
+	// Only a missing module is reported as unavailable
+	if err := fts5Error(fmt.Errorf("no such module: fts5")); !errors.Is(err, ErrFTS5Unavailable) {
+		t.Errorf("Expected ErrFTS5Unavailable, got %v", err)
+	}
+	if err := fts5Error(fmt.Errorf("fts5: syntax error near \"AND\"")); errors.Is(err, ErrFTS5Unavailable) {
+		t.Errorf("Expected syntax error to pass through, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Hooks are optional callbacks run around each statement executed through
+// DB. Nil callbacks are skipped.
+type Hooks struct {
+	// BeforeQuery runs before the statement and returns the context it is
+	// executed with, e.g. to attach baggage or audit metadata
+	BeforeQuery func(ctx context.Context, query string, args []any) context.Context
+	// AfterQuery runs once the statement has executed, with its duration and
+	// error. For queries this is before the rows are read.
+	AfterQuery func(ctx context.Context, query string, args []any, duration time.Duration, err error)
+}
+
+// beforeQuery runs the BeforeQuery hook, if any
+func (db *DB) beforeQuery(ctx context.Context, query string, args []any) context.Context {
+	if db.cfg.Hooks.BeforeQuery == nil {
+		return ctx
+	}
+	return db.cfg.Hooks.BeforeQuery(ctx, query, args)
+}
+
+// afterQuery runs the AfterQuery hook, if any
+func (db *DB) afterQuery(ctx context.Context, query string, args []any, start time.Time, err error) {
+	if db.cfg.Hooks.AfterQuery == nil {
+		return
+	}
+	db.cfg.Hooks.AfterQuery(ctx, query, args, time.Since(start), err)
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// hookKey is the context key set by the BeforeQuery hook under test
+type hookKey struct{}
+
+func TestHooks(t *testing.T) {
+	var events []string
+	var seen []any
+
+	cfg := DefaultConfig()
+	cfg.Hooks = Hooks{
+		BeforeQuery: func(ctx context.Context, query string, args []any) context.Context {
+			events = append(events, "before: "+query)
+			return context.WithValue(ctx, hookKey{}, query)
+		},
+		AfterQuery: func(ctx context.Context, query string, args []any, duration time.Duration, err error) {
+			events = append(events, "after: "+query)
+			seen = append(seen, ctx.Value(hookKey{}), args, err == nil, duration >= 0)
+		},
+	}
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO t (name) VALUES (?)", "alice"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM t WHERE id = ?", 1).Scan(&name); err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if _, err := db.QueryContext(ctx, "SELECT * FROM missing"); err == nil {
+		t.Fatal("Expected error querying missing table, got nil")
+	}
+
+	wantEvents := []string{
+		"before: CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)",
+		"after: CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)",
+		"before: INSERT INTO t (name) VALUES (?)",
+		"after: INSERT INTO t (name) VALUES (?)",
+		"before: SELECT name FROM t WHERE id = ?",
+		"after: SELECT name FROM t WHERE id = ?",
+		"before: SELECT * FROM missing",
+		"after: SELECT * FROM missing",
+	}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("Expected events %q, got %q", wantEvents, events)
+	}
+
+	// AfterQuery sees the context from BeforeQuery, the arguments and the error
+	wantSeen := []any{
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)", []any(nil), true, true,
+		"INSERT INTO t (name) VALUES (?)", []any{"alice"}, true, true,
+		"SELECT name FROM t WHERE id = ?", []any{1}, true, true,
+		"SELECT * FROM missing", []any(nil), false, true,
+	}
+	if !reflect.DeepEqual(seen, wantSeen) {
+		t.Errorf("Expected hook arguments %v, got %v", wantSeen, seen)
+	}
+}
+
+func TestHooksNil(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Hooks = Hooks{AfterQuery: func(context.Context, string, []any, time.Duration, error) {}}
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Only one hook is set, so the other must be skipped
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Errorf("Failed to exec with a partial hook set: %v", err)
+	}
+}
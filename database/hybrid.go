@@ -0,0 +1,98 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// defaultRRFConstant dampens the influence of top ranks in reciprocal rank
+// fusion; 60 is the value from the original RRF paper
+const defaultRRFConstant = 60
+
+// HybridSpec configures a search that fuses FTS5 and vector rankings. Both
+// tables must share rowids, e.g. an FTS5 index and a vector table keyed by
+// the same email id.
+type HybridSpec struct {
+	FTSTable     string
+	Query        string // FTS5 MATCH expression
+	Snippet      SearchOptions
+	VectorTable  string
+	VectorColumn string
+	Vector       []float32
+	TextWeight   float64 // Weight of the keyword ranking; defaults to 1
+	VectorWeight float64 // Weight of the vector ranking; defaults to 1
+	Candidates   int     // Results taken from each ranking; defaults to 5 * Limit
+	RRFConstant  int     // Defaults to 60
+	Limit        int     // Defaults to 10
+}
+
+// HybridSearch runs an FTS5 query and a vector nearest-neighbour search and
+// fuses the two rankings with weighted reciprocal rank fusion. Each result's
+// Score is the negated fused score, so as with bm25 lower values are more
+// relevant. Snippets are filled for rows matched by the FTS5 query when
+// Snippet.Column is set.
+func (db *DB) HybridSearch(ctx context.Context, spec HybridSpec) ([]SearchResult, error) {
+	if spec.TextWeight < 0 || spec.VectorWeight < 0 {
+		return nil, fmt.Errorf("weights must not be negative")
+	}
+	textWeight, vectorWeight := spec.TextWeight, spec.VectorWeight
+	if textWeight == 0 && vectorWeight == 0 {
+		textWeight, vectorWeight = 1, 1
+	}
+
+	limit := spec.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	candidates := spec.Candidates
+	if candidates <= 0 {
+		candidates = 5 * limit
+	}
+	k := spec.RRFConstant
+	if k <= 0 {
+		k = defaultRRFConstant
+	}
+
+	scores := map[int64]float64{}
+	snippets := map[int64]string{}
+
+	if textWeight > 0 {
+		opts := spec.Snippet
+		opts.Limit, opts.Offset = candidates, 0
+		matches, err := db.SearchFTS5(ctx, spec.FTSTable, spec.Query, opts)
+		if err != nil {
+			return nil, fmt.Errorf("hybrid keyword search: %w", err)
+		}
+		for rank, m := range matches {
+			scores[m.RowID] += textWeight / float64(k+rank+1)
+			snippets[m.RowID] = m.Snippet
+		}
+	}
+
+	if vectorWeight > 0 {
+		neighbors, err := db.NearestNeighbors(ctx, spec.VectorTable, spec.VectorColumn, spec.Vector, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("hybrid vector search: %w", err)
+		}
+		for rank, n := range neighbors {
+			scores[n.RowID] += vectorWeight / float64(k+rank+1)
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for rowID, score := range scores {
+		results = append(results, SearchResult{RowID: rowID, Score: -score, Snippet: snippets[rowID]})
+	}
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		if c := cmp.Compare(a.Score, b.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.RowID, b.RowID)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHybridSearch(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.CreateFTS5(ctx, "email_text", FTS5Options{Columns: []string{"body"}}); err != nil {
+		t.Fatalf("Failed to create FTS5 table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "CREATE TABLE email_vectors (id INTEGER PRIMARY KEY, embedding BLOB)"); err != nil {
+		t.Fatalf("Failed to create vector table: %v", err)
+	}
+
+	// Keyword ranking is 1, 2 and semantic ranking is 3, 2: email 2 is the
+	// only one both signals agree on
+	emails := []struct {
+		id     int64
+		body   string
+		vector []float32
+	}{
+		{1, "invoice invoice", []float32{0, 1, 0}},
+		{2, "please find the invoice for last month attached", []float32{0.7, 0.7, 0}},
+		{3, "your billing statement is ready", []float32{1, 0, 0}},
+	}
+	for _, e := range emails {
+		if _, err := db.ExecContext(ctx, "INSERT INTO email_text (rowid, body) VALUES (?, ?)", e.id, e.body); err != nil {
+			t.Fatalf("Failed to insert text: %v", err)
+		}
+		if _, err := db.ExecContext(ctx, "INSERT INTO email_vectors (id, embedding) VALUES (?, ?)", e.id, serializeVector(t, e.vector)); err != nil {
+			t.Fatalf("Failed to insert vector: %v", err)
+		}
+	}
+
+	spec := HybridSpec{
+		FTSTable:     "email_text",
+		Query:        "invoice",
+		VectorTable:  "email_vectors",
+		VectorColumn: "embedding",
+		Vector:       []float32{1, 0, 0},
+		Candidates:   2,
+	}
+
+	cases := []struct {
+		name         string
+		textWeight   float64
+		vectorWeight float64
+		want         []int64
+	}{
+		{"equal weights", 0, 0, []int64{2, 1, 3}},
+		{"favour vectors", 1, 2, []int64{2, 3, 1}},
+		{"keywords only", 1, 0, []int64{1, 2}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			spec.TextWeight, spec.VectorWeight = c.textWeight, c.vectorWeight
+			results, err := db.HybridSearch(ctx, spec)
+			if err != nil {
+				t.Fatalf("Failed to search: %v", err)
+			}
+
+			var got []int64
+			for _, r := range results {
+				got = append(got, r.RowID)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("Expected %v, got %v", c.want, got)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Fatalf("Expected %v, got %v", c.want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// identPattern matches plain SQLite identifiers that are safe to interpolate
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateIdent returns an error if name is not a plain SQL identifier
+func validateIdent(name string) error {
+	if !identPattern.MatchString(name) {
+		return fmt.Errorf("invalid identifier %q", name)
+	}
+	return nil
+}
+
+// quoteLiteral wraps s in single quotes, doubling any embedded quotes
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// QuoteIdent wraps name in double quotes for use as a table or column name,
+// doubling any embedded quotes, so that it cannot break out of the
+// identifier. Any other name SQLite accepts when quoted is allowed,
+// including reserved words; empty names and names containing a null byte
+// are rejected.
+func QuoteIdent(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty identifier")
+	}
+	if strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("invalid identifier %q: contains a null byte", name)
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`, nil
+}
+
+// quoteIdent validates that name is a plain identifier and quotes it. The
+// helpers use it for caller-supplied names, which are held to the stricter
+// rule so that mistakes such as a stray clause fail early.
+func quoteIdent(name string) (string, error) {
+	if err := validateIdent(name); err != nil {
+		return "", err
+	}
+	return QuoteIdent(name)
+}
@@ -0,0 +1,38 @@
+package database
+
+import "testing"
+
+func TestQuoteIdent(t *testing.T) {
+	valid := map[string]string{
+		"emails":              `"emails"`,
+		"received_at":         `"received_at"`,
+		"select":              `"select"`,
+		"order":               `"order"`,
+		"Mixed Case":          `"Mixed Case"`,
+		`x"; DROP TABLE y --`: `"x""; DROP TABLE y --"`,
+		`""`:                  `""""""`,
+	}
+	for name, want := range valid {
+		got, err := QuoteIdent(name)
+		if err != nil {
+			t.Errorf("Failed to quote %q: %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Expected %q to quote as %s, got %s", name, want, got)
+		}
+	}
+
+	for _, name := range []string{"", "emails\x00", "\x00"} {
+		if _, err := QuoteIdent(name); err == nil {
+			t.Errorf("Expected error quoting %q, got nil", name)
+		}
+	}
+
+	// The helpers only accept plain identifiers
+	for _, name := range []string{"emails; DROP TABLE emails", `a"b`, "1st", "with space"} {
+		if _, err := quoteIdent(name); err == nil {
+			t.Errorf("Expected helpers to reject %q, got nil", name)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package sqlutil holds helpers shared by the database backends that need
+// no particular driver
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// IsMemory reports whether path names an in-memory database, either as
+// :memory: or as a file: URI with mode=memory
+func IsMemory(path string) bool {
+	return path == ":memory:" || strings.HasPrefix(path, "file::memory:") || strings.Contains(path, "mode=memory")
+}
+
+// Execer runs statements on a single connection, as *sql.Conn and *sql.Tx do
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// InsertGetID executes an insert on conn and returns the rowid of the new
+// row. If the driver does not report LastInsertId, it is read with
+// last_insert_rowid(), which is why conn must be a single connection.
+func InsertGetID(ctx context.Context, conn Execer, query string, args ...any) (int64, error) {
+	res, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("inserting row: %w", err)
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		return id, nil
+	}
+
+	var id int64
+	if err := conn.QueryRowContext(ctx, "SELECT last_insert_rowid()").Scan(&id); err != nil {
+		return 0, fmt.Errorf("reading last insert rowid: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,46 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestInsertGetID(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE documents (id INTEGER PRIMARY KEY, title TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+	defer conn.Close()
+
+	for want := int64(1); want <= 3; want++ {
+		id, err := InsertGetID(ctx, conn, "INSERT INTO documents (title) VALUES (?)", "doc")
+		if err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		if id != want {
+			t.Errorf("Expected id %d, got %d", want, id)
+		}
+	}
+
+	if _, err := InsertGetID(ctx, conn, "INSERT INTO missing_table VALUES (1)"); err == nil {
+		t.Error("Expected error inserting into a missing table, got nil")
+	}
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// QueryIter runs a query and returns a sequence yielding one scanned row at a
+// time, so large result sets never need to be held in memory. The rows are
+// closed when iteration finishes or stops early; callers must range over the
+// returned sequence exactly once to release the connection.
+func QueryIter[T any](ctx context.Context, db *DB, query string, args ...any) (iter.Seq2[T, error], error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+
+	return func(yield func(T, error) bool) {
+		defer rows.Close()
+
+		for rows.Next() {
+			var v T
+			if err := scanRow(rows, &v, db.Location()); err != nil {
+				yield(v, fmt.Errorf("scanning row: %w", err))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		// Surfaces context cancellation during iteration
+		if err := rows.Err(); err != nil {
+			var zero T
+			yield(zero, fmt.Errorf("iterating rows: %w", err))
+		}
+	}, nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type iterRow struct {
+	ID    int64  `db:"id"`
+	Value string `db:"value"`
+}
+
+// setupIter creates a table with n rows for iteration tests
+func setupIter(t *testing.T, db *DB, n int) {
+	t.Helper()
+
+	_, err := db.Exec("CREATE TABLE iter_test (id INTEGER PRIMARY KEY, value TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		_, err = db.Exec("INSERT INTO iter_test (value) VALUES (?)", fmt.Sprintf("value %d", i))
+		if err != nil {
+			t.Fatalf("Failed to insert data: %v", err)
+		}
+	}
+}
+
+func TestQueryIter(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	setupIter(t, db, 100)
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	seq, err := QueryIter[iterRow](ctx, db, "SELECT id, value FROM iter_test ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+
+	count := 0
+	for row, err := range seq {
+		if err != nil {
+			t.Fatalf("Iteration error: %v", err)
+		}
+		count++
+		if row.ID != int64(count) || row.Value != fmt.Sprintf("value %d", count-1) {
+			t.Errorf("Unexpected row %+v", row)
+		}
+	}
+
+	if count != 100 {
+		t.Errorf("Expected 100 rows, got %d", count)
+	}
+}
+
+func TestQueryIterBreakClosesRows(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	setupIter(t, db, 100)
+
+	seq, err := QueryIter[string](context.Background(), db, "SELECT value FROM iter_test ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+
+	for value, err := range seq {
+		if err != nil {
+			t.Fatalf("Iteration error: %v", err)
+		}
+		if value == "value 2" {
+			break
+		}
+	}
+
+	// The connection should be back in the pool
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("Expected rows to be closed after break, %d connections in use", inUse)
+	}
+}
+
+func TestQueryIterContextCancel(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	setupIter(t, db, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	seq, err := QueryIter[iterRow](ctx, db, "SELECT id, value FROM iter_test ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+
+	var iterErr error
+	count := 0
+	for _, err := range seq {
+		if err != nil {
+			iterErr = err
+			break
+		}
+		count++
+		if count == 10 {
+			cancel()
+		}
+	}
+
+	if !errors.Is(iterErr, context.Canceled) {
+		t.Errorf("Expected context canceled error, got: %v", iterErr)
+	}
+}
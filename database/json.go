@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// jsonPathSegment matches one dotted path component with optional array indices
+var jsonPathSegment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\[[0-9]+\])*$`)
+
+// JSON stores a value of type T in a JSON column
+type JSON[T any] struct {
+	Val T
+}
+
+// Value implements driver.Valuer by marshalling to a JSON string
+func (j JSON[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.Val)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling JSON column: %w", err)
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner by unmarshalling from TEXT or BLOB
+func (j *JSON[T]) Scan(src any) error {
+	var zero T
+	j.Val = zero
+
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		// NULL leaves the zero value
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into JSON column", src)
+	}
+
+	if err := json.Unmarshal(data, &j.Val); err != nil {
+		return fmt.Errorf("unmarshalling JSON column: %w", err)
+	}
+	return nil
+}
+
+// JSONPath validates a dotted path such as "settings.theme" or "tags[0]"
+// and returns it as a SQLite JSON path literal
+func JSONPath(path string) (string, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return "", fmt.Errorf("empty JSON path")
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		if !jsonPathSegment.MatchString(segment) {
+			return "", fmt.Errorf("invalid JSON path segment %q", segment)
+		}
+	}
+
+	return "'$." + path + "'", nil
+}
+
+// JSONExtract builds a json_extract expression for column at path
+func JSONExtract(column, path string) (string, error) {
+	return jsonFunc("json_extract", column, path, false)
+}
+
+// JSONSet builds a json_set expression that assigns a bound parameter at path
+func JSONSet(column, path string) (string, error) {
+	return jsonFunc("json_set", column, path, true)
+}
+
+// JSONInsert builds a json_insert expression that adds a bound parameter at path
+// only if the path does not already exist
+func JSONInsert(column, path string) (string, error) {
+	return jsonFunc("json_insert", column, path, true)
+}
+
+// jsonFunc builds a call to a JSON function with a quoted column and
+// validated path
+func jsonFunc(fn, column, path string, withValue bool) (string, error) {
+	quoted, err := quoteIdent(column)
+	if err != nil {
+		return "", err
+	}
+
+	literal, err := JSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	if withValue {
+		return fmt.Sprintf("%s(%s, %s, ?)", fn, quoted, literal), nil
+	}
+	return fmt.Sprintf("%s(%s, %s)", fn, quoted, literal), nil
+}
+
+// JSONSet assigns value, marshalled to JSON, at the dotted path in the
+// JSON column of every row of table matching where, without reading the
+// documents first. It is suited to toggling one metadata field on many
+// emails. Other fields are kept, and a NULL column is treated as an empty
+// object; as with SQLite's json_set, the parents of path must already
+// exist. An empty where updates every row.
+func (db *DB) JSONSet(ctx context.Context, table, column, path string, value any, where string, args ...any) error {
+	quotedTable, err := quoteIdent(table)
+	if err != nil {
+		return err
+	}
+	quotedColumn, err := quoteIdent(column)
+	if err != nil {
+		return err
+	}
+	literal, err := JSONPath(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshalling value for %s: %w", path, err)
+	}
+
+	query := fmt.Sprintf("UPDATE %[1]s SET %[2]s = json_set(COALESCE(%[2]s, '{}'), %[3]s, json(?))",
+		quotedTable, quotedColumn, literal)
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	if _, err := db.ExecContext(ctx, query, append([]any{string(data)}, args...)...); err != nil {
+		return fmt.Errorf("setting %s in %s.%s: %w", path, table, column, err)
+	}
+	return nil
+}
+
+// JSONField maps one key of the objects built by QueryJSONArray to the SQL
+// expression computing its value, such as a column name or json(metadata)
+type JSONField struct {
+	Key  string
+	Expr string
+}
+
+// QueryJSONArray returns the rows selected by from, the part of a query
+// after FROM, as one JSON array of objects built by SQLite in a single
+// statement, ready to write to an HTTP response without scanning and
+// re-marshalling each row. Each object has the fields' keys, in order, set
+// to their expressions. Text is embedded as a JSON string; wrap an
+// expression in json() to embed a stored document. BLOB values cannot be
+// represented and fail the query. orderBy, if not empty, orders the array
+// elements, since an ORDER BY in from would not. args bind the
+// placeholders in the order they appear: fields, orderBy, then from.
+func (db *DB) QueryJSONArray(ctx context.Context, fields []JSONField, from, orderBy string, args ...any) (json.RawMessage, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("building JSON array: no fields")
+	}
+
+	seen := make(map[string]bool, len(fields))
+	pairs := make([]string, len(fields))
+	for i, field := range fields {
+		if seen[field.Key] {
+			return nil, fmt.Errorf("building JSON array: duplicate key %q", field.Key)
+		}
+		seen[field.Key] = true
+		pairs[i] = quoteLiteral(field.Key) + ", " + field.Expr
+	}
+
+	agg := "json_object(" + strings.Join(pairs, ", ") + ")"
+	if orderBy != "" {
+		agg += " ORDER BY " + orderBy
+	}
+	query := fmt.Sprintf("SELECT json_group_array(%s) FROM %s", agg, strings.TrimRight(strings.TrimSpace(from), ";"))
+
+	var data string
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&data); err != nil {
+		return nil, fmt.Errorf("building JSON array: %w", err)
+	}
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testMetadata struct {
+	Labels   []string `json:"labels"`
+	Settings struct {
+		Theme string `json:"theme"`
+	} `json:"settings"`
+}
+
+func TestJSONColumn(t *testing.T) {
+	// Use in-memory database for testing
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, metadata JSON)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Store a nested struct
+	var in JSON[testMetadata]
+	in.Val.Labels = []string{"inbox", "important"}
+	in.Val.Settings.Theme = "dark"
+
+	_, err = db.ExecContext(ctx, "INSERT INTO emails (id, metadata) VALUES (?, ?)", 1, in)
+	if err != nil {
+		t.Fatalf("Failed to insert JSON: %v", err)
+	}
+
+	var out JSON[testMetadata]
+	err = db.QueryRowContext(ctx, "SELECT metadata FROM emails WHERE id = 1").Scan(&out)
+	if err != nil {
+		t.Fatalf("Failed to scan JSON: %v", err)
+	}
+
+	if len(out.Val.Labels) != 2 || out.Val.Labels[1] != "important" {
+		t.Errorf("Expected labels to round-trip, got %v", out.Val.Labels)
+	}
+	if out.Val.Settings.Theme != "dark" {
+		t.Errorf("Expected theme 'dark', got '%s'", out.Val.Settings.Theme)
+	}
+
+	// The stored value should be usable from SQL
+	var theme string
+	err = db.QueryRowContext(ctx, "SELECT json_extract(metadata, '$.settings.theme') FROM emails WHERE id = 1").Scan(&theme)
+	if err != nil {
+		t.Fatalf("Failed to extract JSON: %v", err)
+	}
+	if theme != "dark" {
+		t.Errorf("Expected 'dark', got '%s'", theme)
+	}
+
+	// NULL leaves the zero value
+	_, err = db.ExecContext(ctx, "INSERT INTO emails (id, metadata) VALUES (2, NULL)")
+	if err != nil {
+		t.Fatalf("Failed to insert NULL: %v", err)
+	}
+
+	out.Val.Settings.Theme = "stale"
+	err = db.QueryRowContext(ctx, "SELECT metadata FROM emails WHERE id = 2").Scan(&out)
+	if err != nil {
+		t.Fatalf("Failed to scan NULL JSON: %v", err)
+	}
+	if out.Val.Labels != nil || out.Val.Settings.Theme != "" {
+		t.Errorf("Expected zero value for NULL, got %+v", out.Val)
+	}
+}
+
+func TestJSONPathBuilders(t *testing.T) {
+	tests := []struct {
+		column string
+		path   string
+		want   string
+	}{
+		{"data", "name", `json_extract("data", '$.name')`},
+		{"data", "tags[0]", `json_extract("data", '$.tags[0]')`},
+		{"metadata", "$.settings.theme", `json_extract("metadata", '$.settings.theme')`},
+		{"metadata", "a.b[1][2].c", `json_extract("metadata", '$.a.b[1][2].c')`},
+	}
+	for _, tt := range tests {
+		got, err := JSONExtract(tt.column, tt.path)
+		if err != nil {
+			t.Errorf("JSONExtract(%q, %q) returned error: %v", tt.column, tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("JSONExtract(%q, %q) = %q, want %q", tt.column, tt.path, got, tt.want)
+		}
+	}
+
+	set, err := JSONSet("metadata", "settings.theme")
+	if err != nil {
+		t.Fatalf("JSONSet returned error: %v", err)
+	}
+	if set != `json_set("metadata", '$.settings.theme', ?)` {
+		t.Errorf("Unexpected JSONSet expression: %s", set)
+	}
+
+	insert, err := JSONInsert("metadata", "labels[0]")
+	if err != nil {
+		t.Fatalf("JSONInsert returned error: %v", err)
+	}
+	if insert != `json_insert("metadata", '$.labels[0]', ?)` {
+		t.Errorf("Unexpected JSONInsert expression: %s", insert)
+	}
+
+	// Malicious paths and columns are rejected
+	invalid := []struct {
+		column string
+		path   string
+	}{
+		{"data", "name') OR 1=1 --"},
+		{"data", "na'me"},
+		{"data", "tags[x]"},
+		{"data", "a..b"},
+		{"data", ""},
+		{"data; DROP TABLE x", "name"},
+	}
+	for _, tt := range invalid {
+		if _, err := JSONExtract(tt.column, tt.path); err == nil {
+			t.Errorf("Expected error for JSONExtract(%q, %q), got nil", tt.column, tt.path)
+		}
+	}
+}
+
+func TestJSONPathQuery(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE json_test (id INTEGER PRIMARY KEY, data JSON)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	jsonData := `{"name": "John", "tags": ["developer", "golang"]}`
+	_, err = db.ExecContext(ctx, "INSERT INTO json_test (data) VALUES (json(?))", jsonData)
+	if err != nil {
+		t.Fatalf("Failed to insert JSON data: %v", err)
+	}
+
+	set, err := JSONSet("data", "tags[0]")
+	if err != nil {
+		t.Fatalf("JSONSet returned error: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "UPDATE json_test SET data = "+set+" WHERE id = 1", "manager")
+	if err != nil {
+		t.Fatalf("Failed to update JSON: %v", err)
+	}
+
+	extract, err := JSONExtract("data", "tags[0]")
+	if err != nil {
+		t.Fatalf("JSONExtract returned error: %v", err)
+	}
+
+	var firstTag string
+	err = db.QueryRowContext(ctx, "SELECT "+extract+" FROM json_test WHERE id = 1").Scan(&firstTag)
+	if err != nil {
+		t.Fatalf("Failed to extract JSON: %v", err)
+	}
+	if firstTag != "manager" {
+		t.Errorf("Expected 'manager', got '%s'", firstTag)
+	}
+
+	// Reserved words are usable as column names
+	if _, err := db.ExecContext(ctx, `ALTER TABLE json_test ADD COLUMN "order" JSON`); err != nil {
+		t.Fatalf("Failed to add column: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE json_test SET "order" = json('{"total": 42}')`); err != nil {
+		t.Fatalf("Failed to update JSON: %v", err)
+	}
+	extract, err = JSONExtract("order", "total")
+	if err != nil {
+		t.Fatalf("JSONExtract returned error: %v", err)
+	}
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT "+extract+" FROM json_test WHERE id = 1").Scan(&total); err != nil {
+		t.Fatalf("Failed to extract JSON: %v", err)
+	}
+	if total != 42 {
+		t.Errorf("Expected 42, got %d", total)
+	}
+}
+
+func TestDBJSONSet(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, folder TEXT, metadata TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO emails (id, folder, metadata) VALUES
+		(1, 'inbox', '{"flags":{"read":false,"starred":true},"labels":["work"]}'),
+		(2, 'inbox', '{"flags":{"read":false}}'),
+		(3, 'spam', '{"flags":{"read":false}}'),
+		(4, 'inbox', NULL)`)
+	if err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	if err := db.JSONSet(ctx, "emails", "metadata", "flags.read", true, "folder = ? AND id < 4", "inbox"); err != nil {
+		t.Fatalf("Failed to set nested field: %v", err)
+	}
+	if err := db.JSONSet(ctx, "emails", "metadata", "snooze", map[string]any{"until": "tomorrow"}, "id = ?", 4); err != nil {
+		t.Fatalf("Failed to set field on NULL document: %v", err)
+	}
+
+	want := map[int]string{
+		1: `{"flags":{"read":true,"starred":true},"labels":["work"]}`,
+		2: `{"flags":{"read":true}}`,
+		3: `{"flags":{"read":false}}`,
+		4: `{"snooze":{"until":"tomorrow"}}`,
+	}
+	for id, expected := range want {
+		var got string
+		if err := db.QueryRowContext(ctx, "SELECT metadata FROM emails WHERE id = ?", id).Scan(&got); err != nil {
+			t.Fatalf("Failed to read row %d: %v", id, err)
+		}
+		if got != expected {
+			t.Errorf("Row %d: expected %s, got %s", id, expected, got)
+		}
+	}
+
+	if err := db.JSONSet(ctx, "emails", "metadata", "flags') --", true, ""); err == nil {
+		t.Error("Expected error for an invalid path, got nil")
+	}
+	if err := db.JSONSet(ctx, "emails", "metadata", "x", func() {}, ""); err == nil {
+		t.Error("Expected error for a value that cannot be marshalled, got nil")
+	}
+}
+
+func TestQueryJSONArray(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT, score REAL, snoozed TEXT, metadata JSON)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO emails (id, subject, score, snoozed, metadata) VALUES
+		(1, 'Hello "world"', 0.5, NULL, '{"labels":["work"]}'),
+		(2, 'Second', 2, 'tomorrow', '{"labels":[]}'),
+		(3, 'Spam', 9, NULL, '{}')`)
+	if err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	fields := []JSONField{
+		{Key: "id", Expr: "id"},
+		{Key: "title", Expr: "subject"},
+		{Key: "score", Expr: "score"},
+		{Key: "snoozed", Expr: "snoozed"},
+		{Key: "meta", Expr: "json(metadata)"},
+	}
+	got, err := db.QueryJSONArray(ctx, fields, "emails WHERE id < ?", "id DESC", 3)
+	if err != nil {
+		t.Fatalf("Failed to query JSON array: %v", err)
+	}
+
+	// The same rows marshalled by hand
+	type row struct {
+		ID      int64           `json:"id"`
+		Title   string          `json:"title"`
+		Score   float64         `json:"score"`
+		Snoozed *string         `json:"snoozed"`
+		Meta    json.RawMessage `json:"meta"`
+	}
+	snoozed := "tomorrow"
+	want, err := json.Marshal([]row{
+		{ID: 2, Title: "Second", Score: 2, Snoozed: &snoozed, Meta: json.RawMessage(`{"labels":[]}`)},
+		{ID: 1, Title: `Hello "world"`, Score: 0.5, Meta: json.RawMessage(`{"labels":["work"]}`)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal rows: %v", err)
+	}
+
+	// Elements keep the requested order
+	var gotRows, wantRows []map[string]any
+	if err := json.Unmarshal(got, &gotRows); err != nil {
+		t.Fatalf("Failed to parse output %s: %v", got, err)
+	}
+	json.Unmarshal(want, &wantRows)
+	if !reflect.DeepEqual(gotRows, wantRows) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	empty, err := db.QueryJSONArray(ctx, fields[:1], "emails WHERE id > 100", "")
+	if err != nil {
+		t.Fatalf("Failed to query empty result: %v", err)
+	}
+	if string(empty) != "[]" {
+		t.Errorf("Expected empty array, got %s", empty)
+	}
+
+	if _, err := db.QueryJSONArray(ctx, []JSONField{{Key: "id", Expr: "id"}, {Key: "id", Expr: "subject"}}, "emails", ""); err == nil {
+		t.Error("Expected error for duplicate keys, got nil")
+	}
+	if _, err := db.QueryJSONArray(ctx, nil, "emails", ""); err == nil {
+		t.Error("Expected error for no fields, got nil")
+	}
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExportJSONL runs query and writes each row to w as a JSON object on its
+// own line, with keys in column order. Integers and floats become JSON
+// numbers, TEXT becomes strings, BLOBs are base64-encoded strings and NULLs
+// are null.
+func (db *DB) ExportJSONL(ctx context.Context, w io.Writer, query string, args ...any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("running export query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("reading columns: %w", err)
+	}
+
+	// Column names are encoded once and reused as object keys
+	keys := make([][]byte, len(columns))
+	for i, name := range columns {
+		if keys[i], err = json.Marshal(name); err != nil {
+			return fmt.Errorf("encoding column %s: %w", name, err)
+		}
+	}
+
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	bw := bufio.NewWriter(w)
+	var line bytes.Buffer
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("scanning row: %w", err)
+		}
+
+		line.Reset()
+		line.WriteByte('{')
+		for i, v := range values {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			data, err := json.Marshal(jsonlValue(v))
+			if err != nil {
+				return fmt.Errorf("encoding column %s: %w", columns[i], err)
+			}
+			line.Write(keys[i])
+			line.WriteByte(':')
+			line.Write(data)
+		}
+		line.WriteString("}\n")
+
+		if _, err := bw.Write(line.Bytes()); err != nil {
+			return fmt.Errorf("writing row: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating rows: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flushing JSONL: %w", err)
+	}
+	return nil
+}
+
+// jsonlValue converts a scanned column value to its JSON representation
+func jsonlValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// ImportJSONL reads one JSON object per line from r and inserts each into
+// table within a single transaction, returning the number of rows inserted
+// or updated. Object keys must match columns of table; lines may use
+// different subsets of columns. Strings for BLOB columns are
+// base64-decoded and nested objects or arrays are stored as JSON text,
+// mirroring ExportJSONL. Blank lines are skipped.
+func (db *DB) ImportJSONL(ctx context.Context, r io.Reader, table string, opts ImportOptions) (int64, error) {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Statements are prepared once per distinct set of keys
+	type prepared struct {
+		ins  importStatement
+		stmt *sql.Stmt
+	}
+	statements := map[string]prepared{}
+	defer func() {
+		for _, p := range statements {
+			p.stmt.Close()
+		}
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var count int64
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(text))
+		dec.UseNumber()
+		var object map[string]any
+		if err := dec.Decode(&object); err != nil {
+			return 0, fmt.Errorf("line %d: decoding JSON: %w", line, err)
+		}
+		if len(object) == 0 {
+			return 0, fmt.Errorf("line %d: empty object", line)
+		}
+
+		columns := make([]string, 0, len(object))
+		for name := range object {
+			columns = append(columns, name)
+		}
+		slices.Sort(columns)
+
+		key := strings.Join(columns, "\x00")
+		p, ok := statements[key]
+		if !ok {
+			ins, err := prepareImport(ctx, tx, table, columns, opts)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: %w", line, err)
+			}
+			stmt, err := tx.PrepareContext(ctx, ins.query)
+			if err != nil {
+				return 0, fmt.Errorf("preparing insert into %s: %w", table, err)
+			}
+			p = prepared{ins: ins, stmt: stmt}
+			statements[key] = p
+		}
+
+		args := make([]any, len(columns))
+		for i, name := range columns {
+			if args[i], err = jsonlArg(object[name], p.ins.blob[i]); err != nil {
+				return 0, fmt.Errorf("line %d: column %s: %w", line, name, err)
+			}
+		}
+
+		res, err := p.stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: inserting into %s: %w", line, table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		count += n
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("reading JSONL: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing import: %w", err)
+	}
+	return count, nil
+}
+
+// jsonlArg converts a decoded JSON value to a statement argument
+func jsonlArg(v any, blob bool) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case string:
+		if blob {
+			return base64.StdEncoding.DecodeString(v)
+		}
+		return v, nil
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	default:
+		// nil and bool are handled by the driver
+		return v, nil
+	}
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLRoundTrip(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		`CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT, size REAL, raw BLOB)`,
+		`CREATE TABLE emails_copy (id INTEGER PRIMARY KEY, subject TEXT, size REAL, raw BLOB)`,
+		`INSERT INTO emails (subject, size, raw) VALUES ('Hello, "world"', 1.5, x'00ff10')`,
+		`INSERT INTO emails (subject, size, raw) VALUES ('Line
+break', NULL, NULL)`,
+		`INSERT INTO emails (subject, size, raw) VALUES (NULL, 3, x'')`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportJSONL(ctx, &buf, "SELECT id, subject, size, raw FROM emails ORDER BY id"); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %q", len(lines), buf.String())
+	}
+	want := `{"id":1,"subject":"Hello, \"world\"","size":1.5,"raw":"AP8Q"}`
+	if lines[0] != want {
+		t.Errorf("Expected first line %s, got %s", want, lines[0])
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("Expected valid JSON, got %s", line)
+		}
+	}
+
+	n, err := db.ImportJSONL(ctx, &buf, "emails_copy", ImportOptions{})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 rows imported, got %d", n)
+	}
+
+	// Both tables must now hold identical rows
+	var original, copied bytes.Buffer
+	if err := db.ExportJSONL(ctx, &original, "SELECT * FROM emails ORDER BY id"); err != nil {
+		t.Fatalf("Failed to export original: %v", err)
+	}
+	if err := db.ExportJSONL(ctx, &copied, "SELECT * FROM emails_copy ORDER BY id"); err != nil {
+		t.Fatalf("Failed to export copy: %v", err)
+	}
+	if original.String() != copied.String() {
+		t.Errorf("Expected round trip to preserve rows\noriginal: %s\ncopy: %s", original.String(), copied.String())
+	}
+
+	var raw []byte
+	if err := db.QueryRowContext(ctx, "SELECT raw FROM emails_copy WHERE id = 1").Scan(&raw); err != nil {
+		t.Fatalf("Failed to read blob: %v", err)
+	}
+	if !reflect.DeepEqual(raw, []byte{0x00, 0xff, 0x10}) {
+		t.Errorf("Expected blob to be decoded, got %v", raw)
+	}
+}
+
+func TestImportJSONL(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, `CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT, payload TEXT, seen INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Lines may use different columns, and nested values are stored as JSON
+	input := `{"kind":"open","payload":{"ip":"127.0.0.1"},"seen":true}
+
+{"id":10,"kind":"click"}
+`
+	n, err := db.ImportJSONL(ctx, strings.NewReader(input), "events", ImportOptions{})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 rows imported, got %d", n)
+	}
+
+	var payload string
+	var seen int
+	if err := db.QueryRowContext(ctx, "SELECT payload, seen FROM events WHERE kind = 'open'").Scan(&payload, &seen); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if payload != `{"ip":"127.0.0.1"}` || seen != 1 {
+		t.Errorf("Expected JSON payload and seen=1, got %q and %d", payload, seen)
+	}
+
+	// A bad line rolls back the whole import
+	input = `{"kind":"bounce"}` + "\n" + `{"kind":` + "\n"
+	if _, err := db.ImportJSONL(ctx, strings.NewReader(input), "events", ImportOptions{}); err == nil {
+		t.Fatal("Expected error for malformed line, got nil")
+	}
+	if count, _ := db.Count(ctx, "events", ""); count != 2 {
+		t.Errorf("Expected failed import to roll back, got %d rows", count)
+	}
+}
@@ -0,0 +1,74 @@
+package libsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// busyConnector sets busy_timeout on every new connection. go-libsql
+// ignores parameters in the DSN, so the pragma cannot be passed there.
+type busyConnector struct {
+	driver.Connector
+	timeout int
+}
+
+// newBusyConnector opens a connector for dsn whose connections wait up to
+// timeout, in milliseconds, for locks to clear
+func newBusyConnector(dsn, timeout string) (*busyConnector, error) {
+	ms, err := strconv.Atoi(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid busy_timeout %q: %w", timeout, err)
+	}
+
+	// database/sql only hands out a registered driver through a DB
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv, ok := db.Driver().(driver.DriverContext)
+	db.Close()
+	if !ok {
+		return nil, fmt.Errorf("driver %s cannot open connectors", DriverName)
+	}
+
+	connector, err := drv.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &busyConnector{Connector: connector, timeout: ms}, nil
+}
+
+// Connect implements driver.Connector
+func (c *busyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The pragma returns the new timeout as a row, which go-libsql refuses
+	// to execute, so it is run as a query
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("setting busy_timeout: driver connection %T cannot run queries", conn)
+	}
+	rows, err := queryer.QueryContext(ctx, "PRAGMA busy_timeout = "+strconv.Itoa(c.timeout), nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("setting busy_timeout: %w", err)
+	}
+	rows.Close()
+	return conn, nil
+}
+
+// Close releases the underlying connector, which go-libsql backs with an
+// open database handle
+func (c *busyConnector) Close() error {
+	if closer, ok := c.Connector.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
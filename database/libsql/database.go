@@ -8,9 +8,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/parsel-email/lib-go/database/internal/sqlutil"
 	_ "github.com/tursodatabase/go-libsql"
 )
 
+// DriverName is the database/sql driver used by Open, matching the name
+// reported by the database package for the same backend
+const DriverName = "libsql"
+
 // Config holds database configuration
 type Config struct {
 	Path            string
@@ -48,9 +53,19 @@ func Open(cfg Config) (*sql.DB, error) {
 		dsn = "file:" + dsn
 	}
 
-	db, err := sql.Open("libsql", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("opening database: %w", err)
+	// Lock waits are irrelevant for a private in-memory database
+	if timeout, ok := cfg.Pragmas["busy_timeout"]; ok && !sqlutil.IsMemory(cfg.Path) {
+		connector, err := newBusyConnector(dsn, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("opening database: %w", err)
+		}
+		db = sql.OpenDB(connector)
+	} else {
+		var err error
+		db, err = sql.Open(DriverName, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("opening database: %w", err)
+		}
 	}
 
 	if db == nil {
@@ -72,6 +87,16 @@ func Open(cfg Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// InsertGetID runs sqlutil.InsertGetID on a connection from db
+func InsertGetID(ctx context.Context, db *sql.DB, query string, args ...any) (int64, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+	return sqlutil.InsertGetID(ctx, conn, query, args...)
+}
+
 // WithContext returns a context with timeout for database operations
 func WithContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout > 0 {
@@ -79,3 +104,14 @@ func WithContext(ctx context.Context, timeout time.Duration) (context.Context, c
 	}
 	return ctx, func() {}
 }
+
+// ServerVersion returns the SQLite version of the connected database. libSQL does not
+// expose its own version over SQL, so this is the SQLite version it is
+// based on.
+func ServerVersion(ctx context.Context, db *sql.DB) (string, error) {
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("reading server version: %w", err)
+	}
+	return version, nil
+}
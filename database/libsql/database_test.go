@@ -2,13 +2,15 @@ package libsql
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/parsel-email/lib-go/database/retry"
 )
 
 func TestDatabaseBasic(t *testing.T) {
@@ -517,71 +519,21 @@ func TestConnectionPool(t *testing.T) {
 			// Create a context with timeout
 			ctx, cancel := WithContext(context.Background(), 5*time.Second)
 
-			// Begin transaction with retry
-			var tx *sql.Tx
-			var err error
-
-			// Try up to 3 times to begin a transaction
-			for attempt := 0; attempt < 3; attempt++ {
-				tx, err = db.BeginTx(ctx, nil)
-				if err == nil {
-					break
-				}
-
-				// If failed, wait a bit before retrying
-				time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
-			}
-
-			if err != nil {
-				errChan <- fmt.Errorf("worker %d failed to begin transaction after retries: %w", id, err)
-				cancel()
-				doneChan <- true
-				return
-			}
-
-			// Insert data with retry
+			// Run the whole transaction again if it hits a lock
 			value := fmt.Sprintf("worker %d - iter %d", id, i)
-			var execErr error
-
-			// Try up to 3 times to execute the query
-			for attempt := 0; attempt < 3; attempt++ {
-				_, execErr = tx.Exec("INSERT INTO pool_test (value) VALUES (?)", value)
-				if execErr == nil {
-					break
-				}
-
-				// If failed and not on last attempt, wait before retrying
-				if attempt < 2 {
-					time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
-				}
-			}
-
-			if execErr != nil {
-				tx.Rollback()
-				errChan <- fmt.Errorf("worker %d failed to insert after retries: %w", id, execErr)
-				cancel()
-				doneChan <- true
-				return
-			}
-
-			// Commit transaction with retry
-			var commitErr error
-
-			// Try up to 3 times to commit
-			for attempt := 0; attempt < 3; attempt++ {
-				commitErr = tx.Commit()
-				if commitErr == nil {
-					break
+			err := retry.Do(ctx, 5, func() error {
+				tx, err := db.BeginTx(ctx, nil)
+				if err != nil {
+					return err
 				}
-
-				// If failed and not on last attempt, wait before retrying
-				if attempt < 2 {
-					time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
+				if _, err := tx.Exec("INSERT INTO pool_test (value) VALUES (?)", value); err != nil {
+					tx.Rollback()
+					return err
 				}
-			}
-
-			if commitErr != nil {
-				errChan <- fmt.Errorf("worker %d failed to commit after retries: %w", id, commitErr)
+				return tx.Commit()
+			})
+			if err != nil {
+				errChan <- fmt.Errorf("worker %d failed to insert: %w", id, err)
 				cancel()
 				doneChan <- true
 				return
@@ -673,3 +625,81 @@ func TestContextTimeout(t *testing.T) {
 		t.Errorf("Expected context deadline exceeded error, got: %v", err)
 	}
 }
+
+func TestInsertGetID(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE documents (id INTEGER PRIMARY KEY, title TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	for want := int64(1); want <= 3; want++ {
+		id, err := InsertGetID(ctx, db, "INSERT INTO documents (title) VALUES (?)", fmt.Sprintf("doc %d", want))
+		if err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		if id != want {
+			t.Errorf("Expected id %d, got %d", want, id)
+		}
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	version, err := ServerVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to read server version: %v", err)
+	}
+	if version == "" {
+		t.Error("Expected a non-empty version")
+	}
+}
+
+func TestBusyTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "busy.db")
+	cfg.Pragmas = DefaultPragmas()
+	cfg.Pragmas["busy_timeout"] = "1234"
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var timeout int
+	if err := db.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatalf("Failed to read busy_timeout: %v", err)
+	}
+	if timeout != 1234 {
+		t.Errorf("Expected busy_timeout 1234, got %d", timeout)
+	}
+
+	// In-memory databases skip it in every DSN form
+	for _, path := range []string{":memory:", "file::memory:", "file:busy?mode=memory"} {
+		if dsn := formatDSN(path, DefaultPragmas()); strings.Contains(dsn, "busy_timeout") {
+			t.Errorf("Expected busy_timeout to be omitted for %s, got %q", path, dsn)
+		}
+	}
+}
@@ -16,6 +16,7 @@ func DefaultPragmas() Pragmas {
 		"cache_size":   "-2000",     // Use up to 2MB of memory for caching
 		"temp_store":   "MEMORY",    // Store temporary tables in memory
 		"mmap_size":    "268435456", // Memory-mapped I/O (256MB)
+		"busy_timeout": "5000",      // Wait up to 5 seconds for locks to clear
 	}
 }
 
@@ -29,6 +30,10 @@ func formatDSN(path string, pragmas Pragmas) string {
 
 	// Add pragmas
 	for key, value := range pragmas {
+		// busy_timeout is set on each connection by Open instead
+		if key == "busy_timeout" {
+			continue
+		}
 		params = append(params, key+"="+value)
 	}
 
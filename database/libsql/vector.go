@@ -0,0 +1,91 @@
+package libsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// identPattern matches plain SQLite identifiers that are safe to interpolate
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ErrVectorIndexUnsupported is returned by CreateVectorIndex when the
+// database does not provide libsql_vector_idx
+var ErrVectorIndexUnsupported = errors.New("libsql_vector_idx not supported")
+
+// ErrVectorTopKUnsupported is returned by VectorTopK when the database does
+// not provide vector_top_k, so callers can fall back to an ORDER BY
+// vector_distance_cos query
+var ErrVectorTopKUnsupported = errors.New("vector_top_k not supported")
+
+// CreateVectorIndex creates a vector index named indexName over the vector
+// column of table, for use with VectorTopK
+func CreateVectorIndex(ctx context.Context, db *sql.DB, table, column, indexName string) error {
+	for _, name := range []string{table, column, indexName} {
+		if !identPattern.MatchString(name) {
+			return fmt.Errorf("invalid identifier %q", name)
+		}
+	}
+
+	stmt := fmt.Sprintf("CREATE INDEX %s ON %s (libsql_vector_idx(%s))", indexName, table, column)
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		if isMissingFunction(err, "libsql_vector_idx") {
+			return fmt.Errorf("%w: %v", ErrVectorIndexUnsupported, err)
+		}
+		return fmt.Errorf("creating vector index %s: %w", indexName, err)
+	}
+	return nil
+}
+
+// VectorTopK returns the rowids of the k nearest neighbours of query using
+// the vector index indexName, nearest first
+func VectorTopK(ctx context.Context, db *sql.DB, indexName string, query []float32, k int) ([]int64, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	if len(query) == 0 {
+		return nil, fmt.Errorf("query vector is empty")
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT id FROM vector_top_k(?, vector32(?), ?)", indexName, vectorLiteral(query), k)
+	if err != nil {
+		if isMissingFunction(err, "vector_top_k") || isMissingFunction(err, "vector32") {
+			return nil, fmt.Errorf("%w: %v", ErrVectorTopKUnsupported, err)
+		}
+		return nil, fmt.Errorf("querying %s: %w", indexName, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning rowid: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating results: %w", err)
+	}
+	return ids, nil
+}
+
+// vectorLiteral formats vec as the text form accepted by vector32
+func vectorLiteral(vec []float32) string {
+	parts := make([]string, len(vec))
+	for i, v := range vec {
+		parts[i] = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// isMissingFunction reports whether err says name is not defined, either as
+// a scalar or a table-valued function
+func isMissingFunction(err error, name string) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no such function: "+name) || strings.Contains(msg, "no such table: "+name)
+}
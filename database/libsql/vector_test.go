@@ -0,0 +1,104 @@
+package libsql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVectorTopK(t *testing.T) {
+	// Use in-memory database for testing
+	cfg := DefaultConfig()
+
+	// Open connection to the database
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE vector_test (id INTEGER PRIMARY KEY, embedding F32_BLOB(4))")
+	if err != nil {
+		if strings.Contains(err.Error(), "near \"F32_BLOB\"") {
+			t.Skip("LibSQL native vector types not supported in this version, skipping test")
+		}
+		t.Fatalf("Failed to create vector table: %v", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO vector_test (embedding) VALUES
+		(vector32('[0.800, 0.579, 0.481, 0.229]')),
+		(vector32('[0.406, 0.027, 0.378, 0.056]')),
+		(vector32('[0.698, 0.140, 0.073, 0.125]')),
+		(vector32('[0.379, 0.637, 0.011, 0.647]'))
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert vectors: %v", err)
+	}
+
+	err = CreateVectorIndex(ctx, db, "vector_test", "embedding", "vector_idx")
+	if errors.Is(err, ErrVectorIndexUnsupported) {
+		t.Skip("LibSQL vector indexing not available, skipping test")
+	}
+	if err != nil {
+		t.Fatalf("Failed to create vector index: %v", err)
+	}
+
+	ids, err := VectorTopK(ctx, db, "vector_idx", []float32{0.064, 0.777, 0.661, 0.687}, 2)
+	if errors.Is(err, ErrVectorTopKUnsupported) {
+		t.Skip("LibSQL vector_top_k not available, skipping test")
+	}
+	if err != nil {
+		t.Fatalf("Failed to run vector_top_k: %v", err)
+	}
+
+	if len(ids) != 2 {
+		t.Fatalf("Expected 2 rowids, got %v", ids)
+	}
+	if ids[0] != 4 {
+		t.Errorf("Expected rowid 4 to be nearest, got %v", ids)
+	}
+
+	if _, err := VectorTopK(ctx, db, "vector_idx", []float32{1, 0, 0, 0}, 0); err == nil {
+		t.Error("Expected error for k = 0, got nil")
+	}
+}
+
+func TestCreateVectorIndexInvalid(t *testing.T) {
+	// Use in-memory database for testing
+	cfg := DefaultConfig()
+
+	// Open connection to the database
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invalid := [][3]string{
+		{"vector_test; DROP TABLE x", "embedding", "idx"},
+		{"vector_test", "embedding)", "idx"},
+		{"vector_test", "embedding", "1idx"},
+	}
+	for _, c := range invalid {
+		if err := CreateVectorIndex(ctx, db, c[0], c[1], c[2]); err == nil {
+			t.Errorf("Expected error for %q, got nil", c)
+		}
+	}
+}
+
+func TestVectorLiteral(t *testing.T) {
+	if got := vectorLiteral([]float32{0.5, -1, 3.25}); got != "[0.5,-1,3.25]" {
+		t.Errorf("Expected [0.5,-1,3.25], got %s", got)
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/parsel-email/lib-go/logger"
+)
+
+// logFieldsKey is the context key for fields attached with WithLogFields
+type logFieldsKey struct{}
+
+// WithLogFields returns a context carrying fields to include in every query
+// logged with it. Fields from an enclosing context are kept unless
+// overridden.
+func WithLogFields(ctx context.Context, fields logger.Fields) context.Context {
+	merged := logger.Fields{}
+	for k, v := range LogFields(ctx) {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFields returns the fields attached to ctx, or nil if there are none
+func LogFields(ctx context.Context) logger.Fields {
+	fields, _ := ctx.Value(logFieldsKey{}).(logger.Fields)
+	return fields
+}
+
+// LogQuery logs a statement with its duration and any error to the
+// configured logger, together with the request and trace IDs and fields
+// carried by ctx. Successful statements are logged at debug level and
+// failures at error level. It does nothing if no logger is configured.
+func (db *DB) LogQuery(ctx context.Context, query string, duration time.Duration, err error) {
+	if db.cfg.Logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("query", query),
+		slog.Duration("duration", duration),
+	}
+	if requestID := logger.GetRequestID(ctx); requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if traceID := logger.GetTraceID(ctx); traceID != "" {
+		attrs = append(attrs, slog.String("trace_id", traceID))
+	}
+
+	// Sort field names so log lines are stable
+	fields := LogFields(ctx)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		db.cfg.Logger.LogAttrs(ctx, slog.LevelError, "query failed", attrs...)
+		return
+	}
+	db.cfg.Logger.LogAttrs(ctx, slog.LevelDebug, "query", attrs...)
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/parsel-email/lib-go/logger"
+)
+
+func TestLogQueryFields(t *testing.T) {
+	// Capture log entries as JSON lines
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := logger.WithRequestID(context.Background(), "req-123")
+	ctx = WithLogFields(ctx, logger.Fields{"handler": "inbox"})
+	ctx = WithLogFields(ctx, logger.Fields{"user_id": 7})
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE logged (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse log entry %q: %v", buf.String(), err)
+	}
+	if entry["query"] != "CREATE TABLE logged (id INTEGER PRIMARY KEY)" {
+		t.Errorf("Expected query to be logged, got %v", entry["query"])
+	}
+	if entry["request_id"] != "req-123" {
+		t.Errorf("Expected request_id 'req-123', got %v", entry["request_id"])
+	}
+	if entry["handler"] != "inbox" || entry["user_id"] != float64(7) {
+		t.Errorf("Expected context fields to propagate, got %v", entry)
+	}
+
+	// Statements without fields log cleanly and failures are logged as errors
+	buf.Reset()
+	if _, err := db.ExecContext(context.Background(), "INSERT INTO missing_table VALUES (1)"); err == nil {
+		t.Fatal("Expected error inserting into a missing table")
+	}
+	line := buf.String()
+	if !strings.Contains(line, `"level":"ERROR"`) || !strings.Contains(line, "no such table") {
+		t.Errorf("Expected failed query to be logged as an error, got %q", line)
+	}
+	if strings.Contains(line, "request_id") || strings.Contains(line, "handler") {
+		t.Errorf("Expected no context fields, got %q", line)
+	}
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ErrTransactionOpen is returned by operations that cannot run while a
+// transaction started with BeginTx is still open
+var ErrTransactionOpen = errors.New("transaction still open")
+
+// IntegrityCheck runs PRAGMA integrity_check and PRAGMA foreign_key_check and
+// returns any reported problems. An empty slice means the database is healthy.
+func (db *DB) IntegrityCheck(ctx context.Context) ([]string, error) {
+	problems := []string{}
+
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("running integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, fmt.Errorf("scanning integrity check: %w", err)
+		}
+		// A single "ok" row indicates no problems
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading integrity check: %w", err)
+	}
+	rows.Close()
+
+	fkRows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("running foreign key check: %w", err)
+	}
+	defer fkRows.Close()
+
+	for fkRows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int64
+		if err := fkRows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return nil, fmt.Errorf("scanning foreign key check: %w", err)
+		}
+		problems = append(problems, fmt.Sprintf(
+			"foreign key violation: %s row %d references missing row in %s (constraint %d)",
+			table, rowid.Int64, parent, fkid))
+	}
+	if err := fkRows.Err(); err != nil {
+		return nil, fmt.Errorf("reading foreign key check: %w", err)
+	}
+
+	return problems, nil
+}
+
+// Vacuum rebuilds the database file to reclaim free space. It is skipped for
+// in-memory databases and refused while a transaction is open.
+func (db *DB) Vacuum(ctx context.Context) error {
+	if isMemory(db.cfg.Path) {
+		return nil
+	}
+	if db.activeTx.Load() > 0 {
+		return fmt.Errorf("vacuuming database: %w", ErrTransactionOpen)
+	}
+
+	if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("vacuuming database: %w", err)
+	}
+	return nil
+}
+
+// CloneTo writes a consistent copy of the database to destPath with VACUUM
+// INTO and opens it with the same configuration. The copy is taken from a
+// single read snapshot, so it is safe while the source is in use. destPath
+// must not already exist.
+func (db *DB) CloneTo(ctx context.Context, destPath string) (*DB, error) {
+	if isMemory(db.cfg.Path) || isRemote(db.cfg.Path) {
+		return nil, fmt.Errorf("cloning database: requires a local file database")
+	}
+	if _, err := os.Stat(destPath); err == nil {
+		return nil, fmt.Errorf("cloning database: %s already exists", destPath)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("cloning database: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", destPath); err != nil {
+		return nil, fmt.Errorf("cloning database: %w", err)
+	}
+
+	cfg := db.cfg
+	cfg.Path = destPath
+	clone, err := Open(cfg)
+	if err != nil {
+		os.Remove(destPath)
+		return nil, fmt.Errorf("opening clone: %w", err)
+	}
+	return clone, nil
+}
+
+// Analyze refreshes query planner statistics for the given tables, or for
+// the whole database when no tables are given
+func (db *DB) Analyze(ctx context.Context, table ...string) error {
+	if len(table) == 0 {
+		if _, err := db.ExecContext(ctx, "ANALYZE"); err != nil {
+			return fmt.Errorf("analyzing database: %w", err)
+		}
+		return nil
+	}
+
+	for _, t := range table {
+		quoted, err := quoteIdent(t)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, "ANALYZE "+quoted); err != nil {
+			return fmt.Errorf("analyzing %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// PlanRow is a single step of an EXPLAIN QUERY PLAN result
+type PlanRow struct {
+	ID     int
+	Parent int
+	Detail string
+}
+
+// ExplainQueryPlan returns the query plan SQLite would use for query
+func (db *DB) ExplainQueryPlan(ctx context.Context, query string, args ...any) ([]PlanRow, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("explaining query: %w", err)
+	}
+	defer rows.Close()
+
+	plan := []PlanRow{}
+	for rows.Next() {
+		var row PlanRow
+		var notUsed int
+		if err := rows.Scan(&row.ID, &row.Parent, &notUsed, &row.Detail); err != nil {
+			return nil, fmt.Errorf("scanning query plan: %w", err)
+		}
+		plan = append(plan, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading query plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// PlanUsesIndex reports whether any step of the plan searches an index
+// rather than scanning a whole table
+func PlanUsesIndex(plan []PlanRow) bool {
+	for _, row := range plan {
+		if strings.HasPrefix(row.Detail, "SEARCH") && strings.Contains(row.Detail, "USING") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntegrityCheck(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE)",
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), subject TEXT)",
+		"CREATE INDEX emails_user_idx ON emails (user_id)",
+		"INSERT INTO users (email) VALUES ('alice@example.com'), ('bob@example.com')",
+		"INSERT INTO emails (user_id, subject) VALUES (1, 'hello'), (2, 'world')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to populate database: %v", err)
+		}
+	}
+
+	problems, err := db.IntegrityCheck(ctx)
+	if err != nil {
+		t.Fatalf("Failed to run integrity check: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+
+	// Introduce a dangling reference with enforcement disabled
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("Failed to disable foreign keys: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT INTO emails (user_id, subject) VALUES (99, 'orphan')"); err != nil {
+		t.Fatalf("Failed to insert orphan row: %v", err)
+	}
+	conn.Close()
+
+	problems, err = db.IntegrityCheck(ctx)
+	if err != nil {
+		t.Fatalf("Failed to run integrity check: %v", err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "emails") {
+		t.Errorf("Expected one foreign key problem on emails, got %v", problems)
+	}
+}
+
+func TestVacuumAndAnalyze(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "maintenance.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, sender TEXT, subject TEXT)",
+		"CREATE INDEX emails_sender_idx ON emails (sender)",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to create schema: %v", err)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		_, err := db.ExecContext(ctx, "INSERT INTO emails (sender, subject) VALUES (?, ?)",
+			fmt.Sprintf("user%d@example.com", i%5), "hello")
+		if err != nil {
+			t.Fatalf("Failed to insert data: %v", err)
+		}
+	}
+
+	if err := db.Analyze(ctx, "emails"); err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	var stats int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_stat1 WHERE tbl = 'emails'").Scan(&stats)
+	if err != nil {
+		t.Fatalf("Failed to query sqlite_stat1: %v", err)
+	}
+	if stats == 0 {
+		t.Error("Expected sqlite_stat1 to be populated")
+	}
+
+	if err := db.Analyze(ctx); err != nil {
+		t.Fatalf("Failed to analyze database: %v", err)
+	}
+
+	if err := db.Vacuum(ctx); err != nil {
+		t.Fatalf("Failed to vacuum: %v", err)
+	}
+
+	// Vacuum is refused while a transaction is open
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if err := db.Vacuum(ctx); !errors.Is(err, ErrTransactionOpen) {
+		t.Errorf("Expected ErrTransactionOpen, got: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback: %v", err)
+	}
+	if err := db.Vacuum(ctx); err != nil {
+		t.Errorf("Expected vacuum to succeed after rollback, got: %v", err)
+	}
+}
+
+func TestVacuumMemory(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Vacuum(context.Background()); err != nil {
+		t.Errorf("Expected vacuum to be skipped for in-memory database, got: %v", err)
+	}
+}
+
+func TestExplainQueryPlan(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, sender TEXT, subject TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	query := "SELECT subject FROM emails WHERE sender = ?"
+
+	plan, err := db.ExplainQueryPlan(ctx, query, "alice@example.com")
+	if err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	if len(plan) == 0 || !strings.HasPrefix(plan[0].Detail, "SCAN") {
+		t.Errorf("Expected full table scan, got %+v", plan)
+	}
+	if PlanUsesIndex(plan) {
+		t.Error("Expected plan not to use an index")
+	}
+
+	_, err = db.ExecContext(ctx, "CREATE INDEX emails_sender_idx ON emails (sender)")
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+
+	plan, err = db.ExplainQueryPlan(ctx, query, "alice@example.com")
+	if err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	if len(plan) == 0 || !strings.HasPrefix(plan[0].Detail, "SEARCH") {
+		t.Errorf("Expected index search, got %+v", plan)
+	}
+	if !PlanUsesIndex(plan) {
+		t.Error("Expected plan to use an index")
+	}
+}
+
+func TestCloneTo(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(dir, "source.db")
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT)",
+		"INSERT INTO emails (subject) VALUES ('hello'), ('world')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to populate database: %v", err)
+		}
+	}
+
+	// Hold an open read while cloning to show the source stays live
+	rows, err := db.QueryContext(ctx, "SELECT id FROM emails")
+	if err != nil {
+		t.Fatalf("Failed to query source: %v", err)
+	}
+	defer rows.Close()
+
+	dest := filepath.Join(dir, "clone.db")
+	clone, err := db.CloneTo(ctx, dest)
+	if err != nil {
+		t.Fatalf("Failed to clone database: %v", err)
+	}
+	defer clone.Close()
+	rows.Close()
+
+	if _, err := clone.ExecContext(ctx, "DELETE FROM emails WHERE subject = 'hello'"); err != nil {
+		t.Fatalf("Failed to mutate clone: %v", err)
+	}
+	if _, err := clone.ExecContext(ctx, "INSERT INTO emails (subject) VALUES ('clone only')"); err != nil {
+		t.Fatalf("Failed to mutate clone: %v", err)
+	}
+
+	var source, cloned []string
+	for _, c := range []struct {
+		db   *DB
+		dest *[]string
+	}{{db, &source}, {clone, &cloned}} {
+		rows, err := c.db.QueryContext(ctx, "SELECT subject FROM emails ORDER BY id")
+		if err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+		for rows.Next() {
+			var subject string
+			if err := rows.Scan(&subject); err != nil {
+				t.Fatalf("Failed to scan: %v", err)
+			}
+			*c.dest = append(*c.dest, subject)
+		}
+		rows.Close()
+	}
+
+	if strings.Join(source, ",") != "hello,world" {
+		t.Errorf("Expected source to be unaffected, got %v", source)
+	}
+	if strings.Join(cloned, ",") != "world,clone only" {
+		t.Errorf("Expected clone to reflect mutations, got %v", cloned)
+	}
+
+	// An existing destination is never overwritten
+	if _, err := db.CloneTo(ctx, dest); err == nil {
+		t.Error("Expected error cloning onto existing file, got nil")
+	}
+}
+
+func TestCloneToMemory(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.CloneTo(context.Background(), filepath.Join(t.TempDir(), "clone.db")); err == nil {
+		t.Error("Expected error cloning in-memory database, got nil")
+	}
+}
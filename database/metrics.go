@@ -0,0 +1,105 @@
+package database
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// operation identifies a kind of statement for metrics
+type operation int
+
+const (
+	opExec operation = iota
+	opQuery
+	opQueryRow
+	opBegin
+	numOperations
+)
+
+// operationNames labels each operation in exported metrics
+var operationNames = [numOperations]string{"exec", "query", "query_row", "begin"}
+
+// queryStats counts statements and failures by operation
+type queryStats struct {
+	queries [numOperations]atomic.Uint64
+	errors  [numOperations]atomic.Uint64
+}
+
+// record counts a statement and whether it failed
+func (s *queryStats) record(op operation, err error) {
+	s.queries[op].Add(1)
+	if err != nil {
+		s.errors[op].Add(1)
+	}
+}
+
+// collector exports pool statistics and statement counts for a DB
+type collector struct {
+	db *DB
+
+	openConns    *prometheus.Desc
+	idleConns    *prometheus.Desc
+	inUseConns   *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+	queries      *prometheus.Desc
+	errors       *prometheus.Desc
+}
+
+// MetricsCollector returns a Prometheus collector for the connection pools
+// and statement counts. Pool statistics are read from sql.DBStats on each
+// scrape; with SplitReadWrite both pools are summed.
+func (db *DB) MetricsCollector(namespace string) prometheus.Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", name), help, labels, nil)
+	}
+
+	return &collector{
+		db:           db,
+		openConns:    desc("open_connections", "The number of established connections, in use and idle"),
+		idleConns:    desc("idle_connections", "The number of idle connections"),
+		inUseConns:   desc("in_use_connections", "The number of connections currently in use"),
+		waitCount:    desc("wait_count_total", "The total number of connections waited for"),
+		waitDuration: desc("wait_duration_seconds_total", "The total time blocked waiting for a connection"),
+		queries:      desc("queries_total", "The total number of statements by operation", "operation"),
+		errors:       desc("query_errors_total", "The total number of failed statements by operation", "operation"),
+	}
+}
+
+// Describe sends the descriptors of all exported metrics
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openConns
+	ch <- c.idleConns
+	ch <- c.inUseConns
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.queries
+	ch <- c.errors
+}
+
+// Collect reads the current pool statistics and statement counts
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.DB.Stats()
+	if c.db.reader != nil {
+		r := c.db.reader.Stats()
+		stats.OpenConnections += r.OpenConnections
+		stats.Idle += r.Idle
+		stats.InUse += r.InUse
+		stats.WaitCount += r.WaitCount
+		stats.WaitDuration += r.WaitDuration
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.openConns, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.inUseConns, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+
+	for op, name := range operationNames {
+		ch <- prometheus.MustNewConstMetric(c.queries, prometheus.CounterValue,
+			float64(c.db.stats.queries[op].Load()), name)
+		ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue,
+			float64(c.db.stats.errors[op].Load()), name)
+	}
+}
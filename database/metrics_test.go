@@ -0,0 +1,64 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(db.MetricsCollector("parsel")); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+
+	if _, err := db.Exec("CREATE TABLE metrics_test (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO missing_table VALUES (1)"); err == nil {
+		t.Fatal("Expected error inserting into a missing table")
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, family := range families {
+		found[family.GetName()] = true
+
+		// Both execs were counted and one of them failed
+		if family.GetName() == "parsel_db_queries_total" || family.GetName() == "parsel_db_query_errors_total" {
+			want := 2.0
+			if family.GetName() == "parsel_db_query_errors_total" {
+				want = 1
+			}
+			for _, m := range family.GetMetric() {
+				if m.GetLabel()[0].GetValue() == "exec" && m.GetCounter().GetValue() != want {
+					t.Errorf("Expected %s{operation=exec} = %v, got %v", family.GetName(), want, m.GetCounter().GetValue())
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{
+		"parsel_db_open_connections",
+		"parsel_db_idle_connections",
+		"parsel_db_in_use_connections",
+		"parsel_db_wait_count_total",
+		"parsel_db_wait_duration_seconds_total",
+		"parsel_db_queries_total",
+		"parsel_db_query_errors_total",
+	} {
+		if !found[name] {
+			t.Errorf("Expected metric family %s", name)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/parsel-email/lib-go/migrations"
+)
+
+// AutoMigrate applies the up migrations in source that the database has not
+// run yet and returns how many were applied. It holds the migration lock
+// while checking and migrating, so instances that start together against
+// the same database migrate only once; the others wait and then find
+// nothing pending. It is the in-process equivalent of the
+// migrate CLI's up command.
+func (db *DB) AutoMigrate(ctx context.Context, source fs.FS) (applied int, err error) {
+	applied, err = migrations.ApplyPending(ctx, db.DB, source)
+	if err != nil {
+		return 0, fmt.Errorf("applying migrations: %w", err)
+	}
+	return applied, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAutoMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "emails.db")
+
+	// Each migration records that it ran; running one twice would fail
+	source := fstest.MapFS{
+		"1_runs.up.sql":     {Data: []byte("CREATE TABLE runs (version INTEGER PRIMARY KEY); INSERT INTO runs VALUES (1);")},
+		"1_runs.down.sql":   {Data: []byte("DROP TABLE runs;")},
+		"2_emails.up.sql":   {Data: []byte("CREATE TABLE emails (id INTEGER PRIMARY KEY); INSERT INTO runs VALUES (2);")},
+		"2_emails.down.sql": {Data: []byte("DROP TABLE emails; DELETE FROM runs WHERE version = 2;")},
+	}
+
+	ctx, cancel := WithContext(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Two instances start against the same database at once
+	const instances = 2
+	applied := make([]int, instances)
+	errs := make([]error, instances)
+
+	var wg sync.WaitGroup
+	for i := 0; i < instances; i++ {
+		cfg := DefaultConfig()
+		cfg.Path = path
+
+		db, err := Open(cfg)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer db.Close()
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			applied[i], errs[i] = db.AutoMigrate(ctx, source)
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Instance %d failed to migrate: %v", i, err)
+		}
+		total += applied[i]
+	}
+	if total != 2 {
+		t.Errorf("Expected 2 migrations applied in total, got %v", applied)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Path = path
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	count, err := db.Count(ctx, "runs", "")
+	if err != nil {
+		t.Fatalf("Failed to count runs: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected each migration to run once, got %d runs", count)
+	}
+
+	// Nothing is pending on a later start
+	n, err := db.AutoMigrate(ctx, source)
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected no migrations applied, got %d", n)
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Null holds a value of type T from a nullable column, such as the optional
+// metadata of an email. Valid is false for NULL.
+type Null[T any] struct {
+	Val   T
+	Valid bool
+}
+
+// NullOf returns a non-NULL value
+func NullOf[T any](v T) Null[T] {
+	return Null[T]{Val: v, Valid: true}
+}
+
+// NullFromPtr returns NULL for a nil pointer and the pointed-to value otherwise
+func NullFromPtr[T any](p *T) Null[T] {
+	if p == nil {
+		return Null[T]{}
+	}
+	return NullOf(*p)
+}
+
+// Ptr returns nil for NULL and a pointer to a copy of the value otherwise
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Val
+	return &v
+}
+
+// Value implements driver.Valuer, storing NULL when not valid. Values that
+// are not driver values themselves, such as int or a custom Valuer, are
+// converted the same way as plain query arguments.
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(n.Val)
+}
+
+// Scan implements sql.Scanner, using the same conversions as scanning into T
+func (n *Null[T]) Scan(src any) error {
+	var s sql.Null[T]
+	if err := s.Scan(src); err != nil {
+		return err
+	}
+	n.Val, n.Valid = s.V, s.Valid
+	return nil
+}
+
+// IsZero reports whether n is NULL, so fields tagged `json:",omitzero"` are
+// left out of JSON when NULL
+func (n Null[T]) IsZero() bool {
+	return !n.Valid
+}
+
+// MarshalJSON encodes NULL as null and any other value as T would be
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Val)
+}
+
+// UnmarshalJSON decodes null as NULL and any other value into T
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*n = Null[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Val); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
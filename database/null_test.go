@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type nullRow struct {
+	ID      int64         `db:"id"`
+	Subject Null[string]  `db:"subject"`
+	Size    Null[int64]   `db:"size"`
+	Score   Null[float64] `db:"score"`
+	Flagged Null[bool]    `db:"flagged"`
+}
+
+func TestNullRoundTrip(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE null_test (id INTEGER PRIMARY KEY, subject TEXT, size INTEGER, score REAL, flagged INTEGER)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	subject := "Hello"
+	rows := []nullRow{
+		{ID: 1},
+		{ID: 2, Subject: NullFromPtr(&subject), Size: NullOf[int64](0), Score: NullOf(1.5), Flagged: NullOf(true)},
+	}
+	for _, r := range rows {
+		_, err := db.ExecContext(ctx, "INSERT INTO null_test (id, subject, size, score, flagged) VALUES (?, ?, ?, ?, ?)",
+			r.ID, r.Subject, r.Size, r.Score, r.Flagged)
+		if err != nil {
+			t.Fatalf("Failed to insert row %d: %v", r.ID, err)
+		}
+	}
+
+	// NULL is stored as SQL NULL, not a zero value
+	var nulls int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM null_test WHERE subject IS NULL AND size IS NULL").Scan(&nulls); err != nil {
+		t.Fatalf("Failed to count NULLs: %v", err)
+	}
+	if nulls != 1 {
+		t.Errorf("Expected 1 row of NULLs, got %d", nulls)
+	}
+
+	seq, err := QueryIter[nullRow](ctx, db, "SELECT id, subject, size, score, flagged FROM null_test ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	var got []nullRow
+	for row, err := range seq {
+		if err != nil {
+			t.Fatalf("Failed to scan row: %v", err)
+		}
+		got = append(got, row)
+	}
+	if len(got) != 2 || got[0] != rows[0] || got[1] != rows[1] {
+		t.Fatalf("Expected %+v, got %+v", rows, got)
+	}
+
+	if got[0].Subject.Ptr() != nil {
+		t.Errorf("Expected nil pointer for NULL, got %v", *got[0].Subject.Ptr())
+	}
+	if p := got[1].Subject.Ptr(); p == nil || *p != "Hello" {
+		t.Errorf("Expected pointer to Hello, got %v", p)
+	}
+	if p := got[1].Size.Ptr(); p == nil || *p != 0 {
+		t.Errorf("Expected pointer to 0, got %v", p)
+	}
+
+	// Types that are not driver values convert like plain arguments
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT ? + 1", NullOf(41)).Scan(&n); err != nil || n != 42 {
+		t.Errorf("Expected 42 from Null[int], got %d: %v", n, err)
+	}
+}
+
+func TestNullJSON(t *testing.T) {
+	type message struct {
+		Subject Null[string] `json:"subject"`
+		Size    Null[int64]  `json:"size,omitzero"`
+	}
+
+	data, err := json.Marshal(message{})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(data) != `{"subject":null}` {
+		t.Errorf("Expected NULL as null and omitted with omitzero, got %s", data)
+	}
+
+	data, err = json.Marshal(message{Subject: NullOf("Hi"), Size: NullOf[int64](0)})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(data) != `{"subject":"Hi","size":0}` {
+		t.Errorf("Expected values to be marshalled, got %s", data)
+	}
+
+	var m message
+	if err := json.Unmarshal([]byte(`{"subject":null,"size":7}`), &m); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if m.Subject.Valid || !m.Size.Valid || m.Size.Val != 7 {
+		t.Errorf("Unexpected unmarshalled value %+v", m)
+	}
+}
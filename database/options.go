@@ -0,0 +1,281 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pragmaValuePattern matches pragma values that are safe to interpolate
+var pragmaValuePattern = regexp.MustCompile(`^-?[A-Za-z0-9_]+$`)
+
+// knownPragmas lists the pragmas that may be read with DB.Pragma
+var knownPragmas = map[string]bool{
+	"application_id":            true,
+	"auto_vacuum":               true,
+	"automatic_index":           true,
+	"busy_timeout":              true,
+	"cache_size":                true,
+	"cache_spill":               true,
+	"cell_size_check":           true,
+	"checkpoint_fullfsync":      true,
+	"data_version":              true,
+	"encoding":                  true,
+	"foreign_keys":              true,
+	"freelist_count":            true,
+	"fullfsync":                 true,
+	"hard_heap_limit":           true,
+	"ignore_check_constraints":  true,
+	"journal_mode":              true,
+	"journal_size_limit":        true,
+	"legacy_alter_table":        true,
+	"locking_mode":              true,
+	"max_page_count":            true,
+	"mmap_size":                 true,
+	"page_count":                true,
+	"page_size":                 true,
+	"query_only":                true,
+	"read_uncommitted":          true,
+	"recursive_triggers":        true,
+	"reverse_unordered_selects": true,
+	"secure_delete":             true,
+	"soft_heap_limit":           true,
+	"synchronous":               true,
+	"temp_store":                true,
+	"threads":                   true,
+	"trusted_schema":            true,
+	"user_version":              true,
+	"wal_autocheckpoint":        true,
+}
+
+// tunedPragmas lists the pragmas collected by DB.AllPragmas
+var tunedPragmas = []string{
+	"journal_mode",
+	"synchronous",
+	"foreign_keys",
+	"cache_size",
+	"temp_store",
+	"mmap_size",
+	"busy_timeout",
+	"page_size",
+	"auto_vacuum",
+	"wal_autocheckpoint",
+	"journal_size_limit",
+	"secure_delete",
+}
+
+// storagePragmas change the database file layout. They are applied in this
+// order before any other pragma, since auto_vacuum and journal_mode=WAL
+// already fix the page size, and only take effect on a new database:
+// page_size before the first table is created, and auto_vacuum before that
+// or after a VACUUM.
+var storagePragmas = []string{"page_size", "auto_vacuum"}
+
+// autoVacuumModes maps the accepted auto_vacuum values to what PRAGMA
+// auto_vacuum reads back
+var autoVacuumModes = map[string]string{
+	"NONE":        "0",
+	"FULL":        "1",
+	"INCREMENTAL": "2",
+	"0":           "0",
+	"1":           "1",
+	"2":           "2",
+}
+
+// validatePragmaValue checks the value of pragmas that accept a restricted
+// range, beyond the syntax check applied to every pragma
+func validatePragmaValue(key, value string) error {
+	switch key {
+	case "page_size":
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 512 || size > 65536 || size&(size-1) != 0 {
+			return fmt.Errorf("invalid page_size %q: must be a power of two between 512 and 65536", value)
+		}
+	case "auto_vacuum":
+		if _, ok := autoVacuumModes[strings.ToUpper(value)]; !ok {
+			return fmt.Errorf("invalid auto_vacuum %q: must be NONE, FULL or INCREMENTAL", value)
+		}
+	case "secure_delete":
+		switch strings.ToUpper(value) {
+		case "ON", "OFF", "FAST", "TRUE", "FALSE", "0", "1", "2":
+		default:
+			return fmt.Errorf("invalid secure_delete %q: must be ON, OFF or FAST", value)
+		}
+	case "wal_autocheckpoint":
+		// Zero or a negative value turns automatic checkpoints off
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("invalid wal_autocheckpoint %q: must be a number of pages", value)
+		}
+	case "journal_size_limit":
+		// A negative value removes the limit
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("invalid journal_size_limit %q: must be a number of bytes", value)
+		}
+	}
+	return nil
+}
+
+// warnIgnoredPragmas logs a warning for each storage pragma in the config
+// that the existing database did not take
+func (db *DB) warnIgnoredPragmas(ctx context.Context) error {
+	if db.cfg.Logger == nil || isRemote(db.cfg.Path) {
+		return nil
+	}
+
+	for _, key := range storagePragmas {
+		want, ok := db.cfg.Pragmas[key]
+		if !ok {
+			continue
+		}
+		if key == "auto_vacuum" {
+			want = autoVacuumModes[strings.ToUpper(want)]
+		}
+
+		got, err := db.Pragma(ctx, key)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			db.cfg.Logger.WarnContext(ctx, "pragma ignored on existing database",
+				slog.String("pragma", key),
+				slog.String("requested", db.cfg.Pragmas[key]),
+				slog.String("current", got),
+				slog.String("hint", "page_size needs a new database; auto_vacuum needs a VACUUM"))
+		}
+	}
+	return nil
+}
+
+// Pragmas represents SQLite/libSQL connection pragmas
+type Pragmas map[string]string
+
+// DefaultPragmas returns the default pragmas for optimized performance. For
+// write-heavy workloads, wal_autocheckpoint (pages, default 1000) and
+// journal_size_limit (bytes) bound how large the WAL grows between
+// checkpoints and how large it stays after one. It leaves page_size and
+// auto_vacuum at the SQLite defaults; set them in Config.Pragmas when
+// creating a database, as they are ignored afterwards.
+func DefaultPragmas() Pragmas {
+	return Pragmas{
+		"journal_mode": "WAL",       // Write-Ahead Logging for better concurrency
+		"synchronous":  "NORMAL",    // Good balance between safety and performance
+		"foreign_keys": "ON",        // Enable foreign key constraints
+		"cache_size":   "-2000",     // Use up to 2MB of memory for caching
+		"temp_store":   "MEMORY",    // Store temporary tables in memory
+		"mmap_size":    "268435456", // Memory-mapped I/O (256MB)
+		"busy_timeout": "5000",      // Wait up to 5 seconds for locks to clear
+	}
+}
+
+// formatDSN formats a DSN (Data Source Name) string with required pragmas
+func formatDSN(path string, pragmas Pragmas) string {
+	// Start with the base path
+	dsn := path
+
+	// Build query parameters
+	var params []string
+
+	// Add pragmas
+	for key, value := range pragmas {
+		// Lock waits are irrelevant for a private in-memory database
+		if key == "busy_timeout" && isMemory(path) {
+			continue
+		}
+		params = append(params, key+"="+value)
+	}
+
+	// Add query string if parameters exist, extending any the path already has
+	if len(params) > 0 {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + strings.Join(params, "&")
+	}
+
+	return dsn
+}
+
+// ParseDSN builds a Config from a connection string such as
+// libsql://host?authToken=... or file:emails.db?cache=shared&journal_mode=WAL.
+// Known pragmas are moved from the query string into Pragmas, on top of the
+// defaults, and authToken into AuthToken. Other parameters of a local DSN,
+// such as cache, mode or driver flags, are kept in Path; remote DSNs may not
+// have any others.
+func ParseDSN(dsn string) (Config, error) {
+	if dsn == "" {
+		return Config{}, fmt.Errorf("parsing DSN: empty string")
+	}
+
+	cfg := DefaultConfig()
+	cfg.Path = dsn
+
+	base, rawQuery, hasQuery := strings.Cut(dsn, "?")
+	if !hasQuery {
+		return cfg, nil
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Config{}, fmt.Errorf("parsing DSN query: %w", err)
+	}
+
+	rest := url.Values{}
+	for key, values := range query {
+		value := values[len(values)-1]
+		switch {
+		case key == "authToken":
+			cfg.AuthToken = value
+		case knownPragmas[key]:
+			if !pragmaValuePattern.MatchString(value) {
+				return Config{}, fmt.Errorf("invalid value %q for pragma %s", value, key)
+			}
+			cfg.Pragmas[key] = value
+		default:
+			rest[key] = values
+		}
+	}
+
+	// The remote driver rejects any query parameter it does not know
+	if isRemote(base) && len(rest) > 0 {
+		for key := range rest {
+			return Config{}, fmt.Errorf("unsupported parameter %q in remote DSN", key)
+		}
+	}
+
+	cfg.Path = base
+	if len(rest) > 0 {
+		cfg.Path += "?" + rest.Encode()
+	}
+	return cfg, nil
+}
+
+// Pragma reads back the current value of a single pragma
+func (db *DB) Pragma(ctx context.Context, name string) (string, error) {
+	if !knownPragmas[name] {
+		return "", fmt.Errorf("unknown pragma %q", name)
+	}
+
+	var value string
+	if err := db.QueryRowContext(ctx, "PRAGMA "+name).Scan(&value); err != nil {
+		return "", fmt.Errorf("reading pragma %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// AllPragmas reads back the commonly tuned pragmas
+func (db *DB) AllPragmas(ctx context.Context) (Pragmas, error) {
+	pragmas := make(Pragmas, len(tunedPragmas))
+	for _, name := range tunedPragmas {
+		value, err := db.Pragma(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		pragmas[name] = value
+	}
+	return pragmas, nil
+}
@@ -0,0 +1,368 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPragma(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "pragma.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mode, err := db.Pragma(ctx, "journal_mode")
+	if err != nil {
+		t.Fatalf("Failed to read journal_mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("Expected journal_mode 'wal', got '%s'", mode)
+	}
+
+	pragmas, err := db.AllPragmas(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read pragmas: %v", err)
+	}
+	if pragmas["foreign_keys"] != "1" {
+		t.Errorf("Expected foreign_keys '1', got '%s'", pragmas["foreign_keys"])
+	}
+	if pragmas["cache_size"] != "-2000" {
+		t.Errorf("Expected cache_size '-2000', got '%s'", pragmas["cache_size"])
+	}
+
+	// Unknown and malicious names are rejected
+	for _, name := range []string{"no_such_pragma", "journal_mode; DROP TABLE x"} {
+		if _, err := db.Pragma(ctx, name); err == nil {
+			t.Errorf("Expected error for pragma %q, got nil", name)
+		}
+	}
+}
+
+func TestOpenInvalidPragma(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Pragmas = Pragmas{"journal_mode": "WAL; DROP TABLE x"}
+
+	if _, err := Open(cfg); err == nil {
+		t.Error("Expected error for invalid pragma value, got nil")
+	}
+}
+
+// countLockErrors runs concurrent write transactions that each hold the lock
+// briefly and returns how many failed because the database was locked
+func countLockErrors(t *testing.T, pragmas Pragmas) int {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "locks.db")
+	cfg.MaxOpenConns = 10
+	cfg.Pragmas = pragmas
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE lock_test (id INTEGER PRIMARY KEY, value INTEGER)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	const workers = 5
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			errs <- func() error {
+				tx, err := db.BeginTxMode(context.Background(), TxImmediate, nil)
+				if err != nil {
+					return err
+				}
+				defer tx.Rollback()
+
+				if _, err := tx.Exec("INSERT INTO lock_test (value) VALUES (?)", id); err != nil {
+					return err
+				}
+				time.Sleep(20 * time.Millisecond)
+				return tx.Commit()
+			}()
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	locked := 0
+	for err := range errs {
+		if err == nil {
+			continue
+		}
+		if !strings.Contains(err.Error(), "locked") && !strings.Contains(err.Error(), "busy") {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		locked++
+	}
+	return locked
+}
+
+func TestDefaultBusyTimeout(t *testing.T) {
+	if DefaultPragmas()["busy_timeout"] != "5000" {
+		t.Fatalf("Expected default busy_timeout '5000', got '%s'", DefaultPragmas()["busy_timeout"])
+	}
+
+	noWait := DefaultPragmas()
+	noWait["busy_timeout"] = "0"
+
+	withoutTimeout := countLockErrors(t, noWait)
+	withTimeout := countLockErrors(t, DefaultPragmas())
+	t.Logf("lock errors: busy_timeout=0 %d, default %d", withoutTimeout, withTimeout)
+
+	if withTimeout != 0 {
+		t.Errorf("Expected no lock errors with the default busy_timeout, got %d", withTimeout)
+	}
+	if withoutTimeout == 0 {
+		t.Errorf("Expected lock errors without a busy_timeout")
+	}
+
+	// In-memory databases never wait on locks, so the pragma is omitted
+	if dsn := formatDSN(":memory:", DefaultPragmas()); strings.Contains(dsn, "busy_timeout") {
+		t.Errorf("Expected busy_timeout to be omitted for :memory:, got %q", dsn)
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	// Remote form: the token and pragmas are extracted
+	cfg, err := ParseDSN("libsql://emails.example.turso.io?authToken=secret&journal_mode=WAL")
+	if err != nil {
+		t.Fatalf("Failed to parse remote DSN: %v", err)
+	}
+	if cfg.Path != "libsql://emails.example.turso.io" {
+		t.Errorf("Expected path without token and pragmas, got %q", cfg.Path)
+	}
+	if cfg.AuthToken != "secret" {
+		t.Errorf("Expected auth token 'secret', got %q", cfg.AuthToken)
+	}
+	if cfg.Pragmas["journal_mode"] != "WAL" {
+		t.Errorf("Expected journal_mode WAL, got %q", cfg.Pragmas["journal_mode"])
+	}
+
+	// Local form: URI parameters and driver flags stay in the path
+	cfg, err = ParseDSN("file:emails.db?cache=shared&_fts5=1&synchronous=FULL&busy_timeout=100")
+	if err != nil {
+		t.Fatalf("Failed to parse local DSN: %v", err)
+	}
+	if cfg.Path != "file:emails.db?_fts5=1&cache=shared" {
+		t.Errorf("Expected path with URI parameters, got %q", cfg.Path)
+	}
+	if cfg.Pragmas["synchronous"] != "FULL" || cfg.Pragmas["busy_timeout"] != "100" {
+		t.Errorf("Expected synchronous FULL and busy_timeout 100, got %v", cfg.Pragmas)
+	}
+	if cfg.Pragmas["foreign_keys"] != "ON" {
+		t.Errorf("Expected defaults to be kept, got %v", cfg.Pragmas)
+	}
+	if cfg.AuthToken != "" {
+		t.Errorf("Expected no auth token, got %q", cfg.AuthToken)
+	}
+
+	// A bare path parses to the defaults
+	cfg, err = ParseDSN("emails.db")
+	if err != nil {
+		t.Fatalf("Failed to parse bare path: %v", err)
+	}
+	if cfg.Path != "emails.db" || cfg.Pragmas["journal_mode"] != "WAL" {
+		t.Errorf("Expected bare path with default pragmas, got %+v", cfg)
+	}
+
+	// Pragma values are validated and remote DSNs take no other parameters
+	for _, dsn := range []string{"", "file:x.db?journal_mode=WAL;DROP", "file:x.db?%zz", "libsql://host?tls=0"} {
+		if _, err := ParseDSN(dsn); err == nil {
+			t.Errorf("Expected error for %q, got nil", dsn)
+		}
+	}
+}
+
+func TestParseDSNRoundTrip(t *testing.T) {
+	pragmas := Pragmas{"journal_mode": "WAL", "cache_size": "-4000", "foreign_keys": "OFF"}
+
+	cfg, err := ParseDSN(formatDSN("file:emails.db?cache=shared", pragmas))
+	if err != nil {
+		t.Fatalf("Failed to parse DSN: %v", err)
+	}
+	if cfg.Path != "file:emails.db?cache=shared" {
+		t.Errorf("Expected path to round trip, got %q", cfg.Path)
+	}
+	for key, value := range pragmas {
+		if cfg.Pragmas[key] != value {
+			t.Errorf("Expected pragma %s=%s, got %q", key, value, cfg.Pragmas[key])
+		}
+	}
+
+	// The parsed config opens a working database
+	cfg, err = ParseDSN(formatDSN(filepath.Join(t.TempDir(), "parsed.db"), pragmas))
+	if err != nil {
+		t.Fatalf("Failed to parse DSN: %v", err)
+	}
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open parsed DSN: %v", err)
+	}
+	defer db.Close()
+
+	value, err := db.Pragma(context.Background(), "cache_size")
+	if err != nil {
+		t.Fatalf("Failed to read cache_size: %v", err)
+	}
+	if value != "-4000" {
+		t.Errorf("Expected cache_size -4000, got %s", value)
+	}
+}
+
+func TestStoragePragmas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	cfg := DefaultConfig()
+	cfg.Path = path
+	cfg.Pragmas["page_size"] = "8192"
+	cfg.Pragmas["auto_vacuum"] = "INCREMENTAL"
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A fresh database takes both, even though journal_mode sorts earlier
+	if size, err := db.Pragma(ctx, "page_size"); err != nil || size != "8192" {
+		t.Errorf("Expected page_size 8192, got %q (%v)", size, err)
+	}
+	if mode, err := db.Pragma(ctx, "auto_vacuum"); err != nil || mode != "2" {
+		t.Errorf("Expected auto_vacuum 2, got %q (%v)", mode, err)
+	}
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	db.Close()
+
+	// Reopening the populated database with another page size logs a warning
+	var logs bytes.Buffer
+	cfg.Pragmas["page_size"] = "16384"
+	cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	db, err = Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	if size, _ := db.Pragma(ctx, "page_size"); size != "8192" {
+		t.Errorf("Expected page_size to stay 8192, got %q", size)
+	}
+	if !strings.Contains(logs.String(), "pragma ignored") || !strings.Contains(logs.String(), "pragma=page_size") {
+		t.Errorf("Expected warning about page_size, got %q", logs.String())
+	}
+	if strings.Contains(logs.String(), "pragma=auto_vacuum") {
+		t.Errorf("Expected no warning about auto_vacuum, got %q", logs.String())
+	}
+
+	// Values are validated before opening
+	for key, value := range map[string]string{"page_size": "1000", "auto_vacuum": "SOMETIMES"} {
+		cfg := DefaultConfig()
+		cfg.Pragmas[key] = value
+		if _, err := Open(cfg); err == nil {
+			t.Errorf("Expected error for %s=%s, got nil", key, value)
+		}
+	}
+}
+
+func TestWALPragmas(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "wal.db")
+	cfg.Pragmas["wal_autocheckpoint"] = "100"
+	cfg.Pragmas["journal_size_limit"] = "67108864"
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Both are per-connection settings, so check more than one connection
+	conns := make([]*sql.Conn, 2)
+	for i := range conns {
+		if conns[i], err = db.Conn(ctx); err != nil {
+			t.Fatalf("Failed to acquire connection: %v", err)
+		}
+		defer conns[i].Close()
+	}
+	for i, conn := range conns {
+		var checkpoint, limit string
+		if err := conn.QueryRowContext(ctx, "PRAGMA wal_autocheckpoint").Scan(&checkpoint); err != nil {
+			t.Fatalf("Failed to read wal_autocheckpoint: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA journal_size_limit").Scan(&limit); err != nil {
+			t.Fatalf("Failed to read journal_size_limit: %v", err)
+		}
+		if checkpoint != "100" || limit != "67108864" {
+			t.Errorf("Connection %d: expected 100 and 67108864, got %s and %s", i, checkpoint, limit)
+		}
+	}
+
+	bad := DefaultConfig()
+	bad.Pragmas["wal_autocheckpoint"] = "often"
+	if _, err := Open(bad); err == nil {
+		t.Error("Expected error for invalid wal_autocheckpoint, got nil")
+	}
+}
+
+func TestSecureDelete(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "secure.db")
+	cfg.SecureDelete = true
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if value, err := db.Pragma(ctx, "secure_delete"); err != nil || value != "1" {
+		t.Errorf("Expected secure_delete 1, got %q (%v)", value, err)
+	}
+
+	// An explicit pragma wins over the flag
+	cfg.Path = filepath.Join(t.TempDir(), "fast.db")
+	cfg.Pragmas["secure_delete"] = "FAST"
+	fast, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer fast.Close()
+
+	if value, err := fast.Pragma(ctx, "secure_delete"); err != nil || value != "2" {
+		t.Errorf("Expected secure_delete 2, got %q (%v)", value, err)
+	}
+}
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// does not match the sort keys
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortKey is one column of a keyset pagination order
+type SortKey struct {
+	Column string // Column name, optionally qualified as table.column
+	Desc   bool
+}
+
+// Keyset pages through query results in the order of its keys, resuming
+// after the last row of the previous page instead of skipping rows with
+// OFFSET, so pages stay stable while rows are inserted. The keys must
+// identify a row uniquely, typically by ending with the primary key, and
+// must not be NULL. Email lists sorted by time then id use:
+//
+//	ks := Keyset{Keys: []SortKey{{Column: "received_at", Desc: true}, {Column: "id", Desc: true}}}
+//	where, args, err := ks.Where(cursor)
+//	order, err := ks.OrderBy()
+//	query := "SELECT id, received_at, subject FROM emails"
+//	if where != "" {
+//		query += " WHERE " + where
+//	}
+//	query += " ORDER BY " + order + " LIMIT 50"
+//	// After scanning the last row:
+//	next, err := ks.Cursor(receivedAt, id)
+type Keyset struct {
+	Keys []SortKey
+}
+
+// OrderBy returns the ORDER BY expression for the keys
+func (k Keyset) OrderBy() (string, error) {
+	if len(k.Keys) == 0 {
+		return "", fmt.Errorf("keyset has no sort keys")
+	}
+
+	parts := make([]string, len(k.Keys))
+	for i, key := range k.Keys {
+		col, err := quoteColumn(key.Column)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = col + " ASC"
+		if key.Desc {
+			parts[i] = col + " DESC"
+		}
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// Where returns the condition selecting the rows after the one cursor was
+// encoded from, and its arguments. An empty cursor selects the first page
+// and returns an empty condition. When all keys sort the same way the
+// condition is a single row value comparison such as
+// (received_at, id) < (?, ?); mixed directions are expanded column by
+// column.
+func (k Keyset) Where(cursor string) (string, []any, error) {
+	if len(k.Keys) == 0 {
+		return "", nil, fmt.Errorf("keyset has no sort keys")
+	}
+	if cursor == "" {
+		return "", nil, nil
+	}
+
+	values, err := decodeCursor(cursor)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(values) != len(k.Keys) {
+		return "", nil, fmt.Errorf("%w: has %d values for %d sort keys", ErrInvalidCursor, len(values), len(k.Keys))
+	}
+
+	cols := make([]string, len(k.Keys))
+	sameDirection := true
+	for i, key := range k.Keys {
+		if cols[i], err = quoteColumn(key.Column); err != nil {
+			return "", nil, err
+		}
+		sameDirection = sameDirection && key.Desc == k.Keys[0].Desc
+	}
+
+	if sameDirection {
+		op := ">"
+		if k.Keys[0].Desc {
+			op = "<"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(cols, ", "), op, placeholders), values, nil
+	}
+
+	// (a > ?) OR (a = ? AND b < ?) OR ...
+	var terms []string
+	var args []any
+	for i, key := range k.Keys {
+		var conds []string
+		for j := 0; j < i; j++ {
+			conds = append(conds, cols[j]+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if key.Desc {
+			op = " < ?"
+		}
+		conds = append(conds, cols[i]+op)
+		args = append(args, values[i])
+		terms = append(terms, "("+strings.Join(conds, " AND ")+")")
+	}
+	return "(" + strings.Join(terms, " OR ") + ")", args, nil
+}
+
+// Cursor encodes the sort key values of the last row of a page, in key
+// order, as an opaque cursor for the next page. Values must be passed as
+// stored in the columns: integers, floats, strings or booleans.
+func (k Keyset) Cursor(values ...any) (string, error) {
+	if len(values) != len(k.Keys) {
+		return "", fmt.Errorf("encoding cursor: got %d values for %d sort keys", len(values), len(k.Keys))
+	}
+	for i, v := range values {
+		if v == nil {
+			return "", fmt.Errorf("encoding cursor: sort key %s is NULL", k.Keys[i].Column)
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the values encoded in cursor, keeping integers as
+// int64 so large ids survive the round trip
+func decodeCursor(cursor string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values []any
+	if err := dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	for i, v := range values {
+		switch v := v.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				values[i] = n
+			} else if f, err := v.Float64(); err == nil {
+				values[i] = f
+			} else {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+			}
+		case string, bool:
+		default:
+			return nil, fmt.Errorf("%w: unsupported value %v", ErrInvalidCursor, v)
+		}
+	}
+	return values, nil
+}
+
+// quoteColumn quotes a column name, optionally qualified by its table
+func quoteColumn(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("invalid column %q", name)
+	}
+	for i, part := range parts {
+		quoted, err := quoteIdent(part)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = quoted
+	}
+	return strings.Join(parts, "."), nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pageThrough reads every page of the keyed emails in pages of size rows
+// and returns the ids in the order seen
+func pageThrough(t *testing.T, db *DB, ctx context.Context, ks Keyset, size int) []int64 {
+	t.Helper()
+
+	order, err := ks.OrderBy()
+	if err != nil {
+		t.Fatalf("Failed to build order: %v", err)
+	}
+
+	var ids []int64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("Pagination did not terminate")
+		}
+
+		where, args, err := ks.Where(cursor)
+		if err != nil {
+			t.Fatalf("Failed to build condition: %v", err)
+		}
+		query := "SELECT id, received_at FROM emails"
+		if where != "" {
+			query += " WHERE " + where
+		}
+		query += " ORDER BY " + order + " LIMIT ?"
+
+		rows, err := db.QueryContext(ctx, query, append(args, size)...)
+		if err != nil {
+			t.Fatalf("Failed to query page: %v", err)
+		}
+		var id, receivedAt int64
+		n := 0
+		for rows.Next() {
+			if err := rows.Scan(&id, &receivedAt); err != nil {
+				t.Fatalf("Failed to scan row: %v", err)
+			}
+			ids = append(ids, id)
+			n++
+		}
+		rows.Close()
+
+		if n < size {
+			return ids
+		}
+		if cursor, err = ks.Cursor(receivedAt, id); err != nil {
+			t.Fatalf("Failed to encode cursor: %v", err)
+		}
+	}
+}
+
+func TestKeysetPagination(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, received_at INTEGER NOT NULL)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	// 23 rows sharing only 5 timestamps, so pages split runs of duplicates
+	for i := 0; i < 23; i++ {
+		if _, err := db.ExecContext(ctx, "INSERT INTO emails (received_at) VALUES (?)", 1700000000+(i*7)%5); err != nil {
+			t.Fatalf("Failed to insert data: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		keys []SortKey
+	}{
+		{"descending", []SortKey{{Column: "received_at", Desc: true}, {Column: "id", Desc: true}}},
+		{"ascending", []SortKey{{Column: "received_at"}, {Column: "emails.id"}}},
+		{"mixed", []SortKey{{Column: "received_at", Desc: true}, {Column: "id"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := Keyset{Keys: tt.keys}
+			order, err := ks.OrderBy()
+			if err != nil {
+				t.Fatalf("Failed to build order: %v", err)
+			}
+
+			var want []int64
+			rows, err := db.QueryContext(ctx, "SELECT id FROM emails ORDER BY "+order)
+			if err != nil {
+				t.Fatalf("Failed to query: %v", err)
+			}
+			for rows.Next() {
+				var id int64
+				rows.Scan(&id)
+				want = append(want, id)
+			}
+			rows.Close()
+
+			for _, size := range []int{1, 4, 5, 23, 50} {
+				if got := pageThrough(t, db, ctx, ks, size); !slices.Equal(got, want) {
+					t.Errorf("Page size %d: expected %v, got %v", size, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	ks := Keyset{Keys: []SortKey{{Column: "received_at", Desc: true}, {Column: "id", Desc: true}}}
+
+	cursor, err := ks.Cursor(int64(1700000000), int64(1<<53+1))
+	if err != nil {
+		t.Fatalf("Failed to encode cursor: %v", err)
+	}
+	where, args, err := ks.Where(cursor)
+	if err != nil {
+		t.Fatalf("Failed to build condition: %v", err)
+	}
+	if where != `("received_at", "id") < (?, ?)` {
+		t.Errorf("Expected row value comparison, got %s", where)
+	}
+	// Large ids survive the round trip exactly
+	if !slices.Equal(args, []any{int64(1700000000), int64(1<<53 + 1)}) {
+		t.Errorf("Unexpected arguments %v", args)
+	}
+
+	mixed := Keyset{Keys: []SortKey{{Column: "received_at", Desc: true}, {Column: "id"}}}
+	where, args, err = mixed.Where(cursor)
+	if err != nil {
+		t.Fatalf("Failed to build condition: %v", err)
+	}
+	if where != `(("received_at" < ?) OR ("received_at" = ? AND "id" > ?))` || len(args) != 3 {
+		t.Errorf("Unexpected mixed condition %s %v", where, args)
+	}
+
+	for _, bad := range []string{"not base64!", "e30", cursor[:len(cursor)-4]} {
+		if _, _, err := ks.Where(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", bad, err)
+		}
+	}
+
+	short, _ := Keyset{Keys: ks.Keys[:1]}.Cursor(int64(1))
+	if _, _, err := ks.Where(short); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a cursor of other keys, got %v", err)
+	}
+
+	if _, err := ks.Cursor(nil, int64(1)); err == nil {
+		t.Error("Expected error encoding a NULL sort key, got nil")
+	}
+
+	bad := Keyset{Keys: []SortKey{{Column: "id; DROP TABLE emails"}}}
+	if _, err := bad.OrderBy(); err == nil || !strings.Contains(err.Error(), "invalid identifier") {
+		t.Errorf("Expected invalid identifier error, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier runs statements. It is satisfied by *DB, *Transaction and
+// *Session, so code that accepts it works inside or outside a transaction
+// and can be tested with a fake.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Beginner starts transactions. It is satisfied by *DB and *Session.
+type Beginner interface {
+	BeginTx(ctx context.Context) (*Transaction, error)
+}
+
+var (
+	_ Querier  = (*DB)(nil)
+	_ Querier  = (*Transaction)(nil)
+	_ Querier  = (*Session)(nil)
+	_ Beginner = (*DB)(nil)
+	_ Beginner = (*Session)(nil)
+)
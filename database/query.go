@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parsel-email/lib-go/database/internal/sqlutil"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNotFound is returned when a query expected to return a row returns
+// none. It wraps sql.ErrNoRows, so either can be matched with errors.Is.
+var ErrNotFound = fmt.Errorf("not found: %w", sql.ErrNoRows)
+
+// Get runs query and scans the first row into dest, which may be a struct
+// pointer mapped by `db` tags or any other scan destination. It returns
+// ErrNotFound when the query returns no rows.
+func (db *DB) Get(ctx context.Context, dest any, query string, args ...any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("running query: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("running query: %w", err)
+		}
+		return ErrNotFound
+	}
+	if err := scanRow(rows, dest, db.Location()); err != nil {
+		return fmt.Errorf("scanning row: %w", err)
+	}
+	return rows.Close()
+}
+
+// Count returns the number of rows in table matching the optional where clause
+func (db *DB) Count(ctx context.Context, table string, where string, args ...any) (int64, error) {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COUNT(*) FROM " + quoted
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	var count int64
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting rows in %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// Exists reports whether any row in table matches the optional where clause
+func (db *DB) Exists(ctx context.Context, table string, where string, args ...any) (bool, error) {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return false, err
+	}
+
+	inner := "SELECT 1 FROM " + quoted
+	if where != "" {
+		inner += " WHERE " + where
+	}
+
+	var exists bool
+	query := "SELECT EXISTS(" + inner + " LIMIT 1)"
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking rows in %s: %w", table, err)
+	}
+	return exists, nil
+}
+
+// InsertReturning inserts values into table and returns the requested columns
+// of the new row using a RETURNING clause
+func (db *DB) InsertReturning(ctx context.Context, table string, values map[string]any, returning []string) (map[string]any, error) {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(returning) == 0 {
+		return nil, fmt.Errorf("at least one returning column is required")
+	}
+
+	// Sort columns so the generated SQL is deterministic
+	columns := make([]string, 0, len(values))
+	for col := range values {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	quotedCols := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, col := range columns {
+		if quotedCols[i], err = quoteIdent(col); err != nil {
+			return nil, err
+		}
+		args[i] = values[col]
+	}
+
+	quotedReturning := make([]string, len(returning))
+	for i, col := range returning {
+		if quotedReturning[i], err = quoteIdent(col); err != nil {
+			return nil, err
+		}
+	}
+
+	query := "INSERT INTO " + quoted
+	if len(columns) == 0 {
+		query += " DEFAULT VALUES"
+	} else {
+		placeholders := strings.Repeat("?, ", len(columns)-1) + "?"
+		query += fmt.Sprintf(" (%s) VALUES (%s)", strings.Join(quotedCols, ", "), placeholders)
+	}
+	query += " RETURNING " + strings.Join(quotedReturning, ", ")
+
+	dest := make([]any, len(returning))
+	ptrs := make([]any, len(returning))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+
+	if err := db.QueryRowContext(ctx, query, args...).Scan(ptrs...); err != nil {
+		return nil, fmt.Errorf("inserting into %s: %w", table, err)
+	}
+
+	result := make(map[string]any, len(returning))
+	for i, col := range returning {
+		result[col] = dest[i]
+	}
+	return result, nil
+}
+
+// InsertGetID runs sqlutil.InsertGetID in a transaction, so the insert is
+// refused after Shutdown, serialized with other writes, and runs the hooks
+// and metrics of ExecContext
+func (db *DB) InsertGetID(ctx context.Context, query string, args ...any) (int64, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var span trace.Span
+	if db.cfg.Tracer != nil {
+		ctx, span = db.startSpan(ctx, "db.Exec", query)
+		defer span.End()
+	}
+
+	ctx = db.beforeQuery(ctx, query, args)
+	start := time.Now()
+	id, err := sqlutil.InsertGetID(ctx, tx, query, args...)
+	db.observe(ctx, span, opExec, query, start, err)
+	db.afterQuery(ctx, query, args, start, err)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing insert: %w", err)
+	}
+	return id, nil
+}
+
+// Statement is a query and its arguments, as run by ExecMany
+type Statement struct {
+	Query string
+	Args  []any
+}
+
+// ExecMany executes statements in order within one transaction on a single
+// connection and returns their results. If any statement fails, all are
+// rolled back and the error names the failing statement by index.
+func (db *DB) ExecMany(ctx context.Context, statements []Statement) ([]sql.Result, error) {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]sql.Result, len(statements))
+	for i, stmt := range statements {
+		if results[i], err = tx.ExecContext(ctx, stmt.Query, stmt.Args...); err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing statements: %w", err)
+	}
+	return results, nil
+}
+
+// ExecAffected executes a statement and returns the number of rows it
+// changed
+func (db *DB) ExecAffected(ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("reading rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteByIDs deletes the rows of table whose idColumn is in ids and returns
+// the number deleted. The ids are split into statements under the bound
+// parameter limit, all run in one transaction.
+func (db *DB) DeleteByIDs(ctx context.Context, table, idColumn string, ids []int64) (int64, error) {
+	qt, err := quoteIdent(table)
+	if err != nil {
+		return 0, err
+	}
+	qc, err := quoteIdent(idColumn)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var count int64
+	for start := 0; start < len(ids); start += maxParams {
+		end := min(start+maxParams, len(ids))
+
+		args := make([]any, end-start)
+		for i, id := range ids[start:end] {
+			args[i] = id
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", end-start), ", ")
+		stmt := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", qt, qc, placeholders)
+
+		res, err := tx.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return 0, fmt.Errorf("deleting from %s: %w", table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("deleting from %s: %w", table, err)
+		}
+		count += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing delete: %w", err)
+	}
+	return count, nil
+}
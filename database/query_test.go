@@ -0,0 +1,398 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountAndExists(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE count_test (id INTEGER PRIMARY KEY, value TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Empty table
+	count, err := db.Count(ctx, "count_test", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 rows, got %d", count)
+	}
+
+	exists, err := db.Exists(ctx, "count_test", "")
+	if err != nil {
+		t.Fatalf("Failed to check existence: %v", err)
+	}
+	if exists {
+		t.Error("Expected no rows to exist")
+	}
+
+	// Non-empty table
+	for _, v := range []string{"a", "b", "b"} {
+		if _, err := db.ExecContext(ctx, "INSERT INTO count_test (value) VALUES (?)", v); err != nil {
+			t.Fatalf("Failed to insert data: %v", err)
+		}
+	}
+
+	count, err = db.Count(ctx, "count_test", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows, got %d", count)
+	}
+
+	count, err = db.Count(ctx, "count_test", "value = ?", "b")
+	if err != nil {
+		t.Fatalf("Failed to count filtered rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got %d", count)
+	}
+
+	exists, err = db.Exists(ctx, "count_test", "value = ?", "a")
+	if err != nil {
+		t.Fatalf("Failed to check existence: %v", err)
+	}
+	if !exists {
+		t.Error("Expected row to exist")
+	}
+
+	exists, err = db.Exists(ctx, "count_test", "value = ?", "z")
+	if err != nil {
+		t.Fatalf("Failed to check existence: %v", err)
+	}
+	if exists {
+		t.Error("Expected no matching row")
+	}
+
+	// Unsafe table names are rejected
+	if _, err := db.Count(ctx, "count_test; DROP TABLE count_test", ""); err == nil {
+		t.Error("Expected error for invalid table name, got nil")
+	}
+}
+
+func TestInsertReturning(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE documents (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			content TEXT,
+			created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	for i := int64(1); i <= 2; i++ {
+		row, err := db.InsertReturning(ctx, "documents",
+			map[string]any{"title": "Golang Database", "content": "How to use database/sql"},
+			[]string{"id", "created_at"})
+		if err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+
+		if id, ok := row["id"].(int64); !ok || id != i {
+			t.Errorf("Expected id %d, got %v", i, row["id"])
+		}
+
+		createdAt, ok := row["created_at"].(string)
+		if !ok || createdAt == "" {
+			t.Errorf("Expected default timestamp, got %v", row["created_at"])
+		}
+		if _, err := time.Parse(time.DateTime, createdAt); err != nil {
+			t.Errorf("Expected parseable timestamp, got %q: %v", createdAt, err)
+		}
+	}
+
+	// Invalid identifiers are rejected
+	_, err = db.InsertReturning(ctx, "documents",
+		map[string]any{"title) VALUES ('x'); --": "x"}, []string{"id"})
+	if err == nil {
+		t.Error("Expected error for invalid column name, got nil")
+	}
+}
+
+func TestInsertGetID(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE documents (id INTEGER PRIMARY KEY, title TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	for want := int64(1); want <= 3; want++ {
+		id, err := db.InsertGetID(ctx, "INSERT INTO documents (title) VALUES (?)", "doc")
+		if err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		if id != want {
+			t.Errorf("Expected id %d, got %d", want, id)
+		}
+	}
+
+	if _, err := db.InsertGetID(ctx, "INSERT INTO missing_table VALUES (1)"); err == nil {
+		t.Error("Expected error inserting into a missing table, got nil")
+	}
+}
+
+func TestInsertGetIDThroughDB(t *testing.T) {
+	var queries []string
+	cfg := DefaultConfig()
+	cfg.Hooks.AfterQuery = func(ctx context.Context, query string, args []any, duration time.Duration, err error) {
+		queries = append(queries, query)
+	}
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE documents (id INTEGER PRIMARY KEY, title TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// The insert runs the hooks like any other write
+	insert := "INSERT INTO documents (title) VALUES (?)"
+	if _, err := db.InsertGetID(ctx, insert, "doc"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if len(queries) != 2 || queries[1] != insert {
+		t.Errorf("Expected the insert to reach AfterQuery, got %q", queries)
+	}
+
+	// and is refused once the database is shutting down
+	if err := db.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+	if _, err := db.InsertGetID(ctx, insert, "late"); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Expected ErrShutdown, got %v", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT, read_at TEXT)",
+		"INSERT INTO emails (subject) VALUES ('hello')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	// Structs are filled by column name
+	var email struct {
+		ID      int64
+		Subject string
+		ReadAt  sql.NullString `db:"read_at"`
+	}
+	if err := db.Get(ctx, &email, "SELECT id, subject, read_at FROM emails WHERE id = ?", 1); err != nil {
+		t.Fatalf("Failed to get email: %v", err)
+	}
+	if email.ID != 1 || email.Subject != "hello" || email.ReadAt.Valid {
+		t.Errorf("Expected email 1 with subject hello, got %+v", email)
+	}
+
+	// Single values scan directly
+	var subject string
+	if err := db.Get(ctx, &subject, "SELECT subject FROM emails WHERE id = ?", 1); err != nil {
+		t.Fatalf("Failed to get subject: %v", err)
+	}
+	if subject != "hello" {
+		t.Errorf("Expected hello, got %q", subject)
+	}
+
+	// A missing row is reported as ErrNotFound and still matches sql.ErrNoRows
+	err = db.Get(ctx, &subject, "SELECT subject FROM emails WHERE id = ?", 42)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected error to wrap sql.ErrNoRows, got %v", err)
+	}
+
+	// Other failures are not reported as not found
+	err = db.Get(ctx, &subject, "SELECT subject FROM missing_table")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a query error, got %v", err)
+	}
+}
+
+func TestExecMany(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results, err := db.ExecMany(ctx, []Statement{
+		{Query: "CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT NOT NULL)"},
+		{Query: "INSERT INTO emails (subject) VALUES (?)", Args: []any{"first"}},
+		{Query: "INSERT INTO emails (subject) VALUES (?), (?)", Args: []any{"second", "third"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute statements: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if n, err := results[2].RowsAffected(); err != nil || n != 2 {
+		t.Errorf("Expected 2 rows affected by the last statement, got %d (%v)", n, err)
+	}
+
+	// A failure on the second statement rolls back the first
+	_, err = db.ExecMany(ctx, []Statement{
+		{Query: "INSERT INTO emails (subject) VALUES (?)", Args: []any{"fourth"}},
+		{Query: "INSERT INTO emails (subject) VALUES (?)", Args: []any{nil}},
+		{Query: "INSERT INTO emails (subject) VALUES (?)", Args: []any{"fifth"}},
+	})
+	if err == nil {
+		t.Fatal("Expected error from the second statement, got nil")
+	}
+	if !strings.Contains(err.Error(), "statement 1") {
+		t.Errorf("Expected error to name statement 1, got %v", err)
+	}
+
+	count, err := db.Count(ctx, "emails", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows after rollback, got %d", count)
+	}
+}
+
+func TestExecAffected(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, folder TEXT)",
+		"INSERT INTO emails (folder) VALUES ('inbox'), ('inbox'), ('inbox'), ('sent')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	n, err := db.ExecAffected(ctx, "UPDATE emails SET folder = ? WHERE folder = ?", "archive", "inbox")
+	if err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 rows affected, got %d", n)
+	}
+
+	// Nothing matches now, so no rows change
+	n, err = db.ExecAffected(ctx, "UPDATE emails SET folder = ? WHERE folder = ?", "archive", "inbox")
+	if err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected 0 rows affected, got %d", n)
+	}
+
+	if _, err := db.ExecAffected(ctx, "UPDATE missing SET folder = 'x'"); err == nil {
+		t.Error("Expected error updating a missing table, got nil")
+	}
+}
+
+func TestDeleteByIDs(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT);
+		WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n WHERE x < 2500)
+		INSERT INTO emails (id, subject) SELECT x, 'email ' || x FROM n;
+	`)
+	if err != nil {
+		t.Fatalf("Failed to set up: %v", err)
+	}
+
+	// 2000 existing ids span three chunks, plus some that do not exist
+	ids := make([]int64, 0, 2005)
+	for id := int64(1); id <= 2000; id++ {
+		ids = append(ids, id)
+	}
+	ids = append(ids, 3001, 3002, 3003, 3004, 3005)
+
+	n, err := db.DeleteByIDs(ctx, "emails", "id", ids)
+	if err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if n != 2000 {
+		t.Errorf("Expected 2000 rows deleted, got %d", n)
+	}
+
+	count, err := db.Count(ctx, "emails", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 500 {
+		t.Errorf("Expected 500 rows left, got %d", count)
+	}
+
+	if _, err := db.DeleteByIDs(ctx, "emails", "id; --", ids); err == nil {
+		t.Error("Expected error for invalid column name, got nil")
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+
+	"github.com/parsel-email/lib-go/database/retry"
+)
+
+// resetConnector wraps a remote connector so connections that see a reset
+// are discarded by the pool instead of being reused
+type resetConnector struct {
+	driver.Connector
+}
+
+// Connect implements driver.Connector
+func (c *resetConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &resetConn{Conn: conn}, nil
+}
+
+// resetConn marks itself bad after a connection reset. The error is
+// returned as is rather than as driver.ErrBadConn, which would make
+// database/sql silently re-run statements that may have been applied;
+// retry.Do decides whether to run them again.
+type resetConn struct {
+	driver.Conn
+	bad atomic.Bool
+}
+
+// check marks the connection bad if err is a connection reset
+func (c *resetConn) check(err error) error {
+	if err != nil && retry.IsConnReset(err) {
+		c.bad.Store(true)
+	}
+	return err
+}
+
+// IsValid implements driver.Validator so the pool drops bad connections
+func (c *resetConn) IsValid() bool {
+	if c.bad.Load() {
+		return false
+	}
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// ResetSession implements driver.SessionResetter
+func (c *resetConn) ResetSession(ctx context.Context) error {
+	if c.bad.Load() {
+		return driver.ErrBadConn
+	}
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return c.check(resetter.ResetSession(ctx))
+	}
+	return nil
+}
+
+// Ping implements driver.Pinger
+func (c *resetConn) Ping(ctx context.Context) error {
+	if c.bad.Load() {
+		return driver.ErrBadConn
+	}
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return c.check(pinger.Ping(ctx))
+	}
+	return nil
+}
+
+// ExecContext implements driver.ExecerContext
+func (c *resetConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	res, err := execer.ExecContext(ctx, query, args)
+	return res, c.check(err)
+}
+
+// QueryContext implements driver.QueryerContext
+func (c *resetConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	return rows, c.check(err)
+}
+
+// PrepareContext implements driver.ConnPrepareContext
+func (c *resetConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err := preparer.PrepareContext(ctx, query)
+		return stmt, c.check(err)
+	}
+	stmt, err := c.Conn.Prepare(query)
+	return stmt, c.check(err)
+}
+
+// BeginTx implements driver.ConnBeginTx
+func (c *resetConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err := beginner.BeginTx(ctx, opts)
+		return tx, c.check(err)
+	}
+	tx, err := c.Conn.Begin()
+	return tx, c.check(err)
+}
+
+// CheckNamedValue implements driver.NamedValueChecker
+func (c *resetConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/parsel-email/lib-go/database/retry"
+)
+
+// stubConnector hands out stubConns, the first of which is reset by the
+// server on its first statement
+type stubConnector struct {
+	mu    sync.Mutex
+	conns []*stubConn
+}
+
+func (c *stubConnector) Connect(_ context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conn := &stubConn{reset: len(c.conns) == 0}
+	c.conns = append(c.conns, conn)
+	return conn, nil
+}
+
+func (c *stubConnector) Driver() driver.Driver {
+	return nil
+}
+
+// stubConn is a remote connection that executes statements without effect
+type stubConn struct {
+	reset  bool
+	execs  int
+	closed bool
+}
+
+func (c *stubConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	if c.reset {
+		c.reset = false
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	}
+	c.execs++
+	return driver.RowsAffected(1), nil
+}
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *stubConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestRetryConnectionReset(t *testing.T) {
+	stub := &stubConnector{}
+	pool := sql.OpenDB(&resetConnector{Connector: stub})
+	defer pool.Close()
+	pool.SetMaxOpenConns(1)
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attempts := 0
+	err := retry.Do(ctx, 3, func() error {
+		attempts++
+		_, err := pool.ExecContext(ctx, "INSERT INTO emails DEFAULT VALUES")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute after reset: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if len(stub.conns) != 2 {
+		t.Fatalf("Expected a fresh connection after the reset, got %d connections", len(stub.conns))
+	}
+	if !stub.conns[0].closed {
+		t.Error("Expected the reset connection to be discarded")
+	}
+	if stub.conns[1].execs != 1 {
+		t.Errorf("Expected the statement to run on the new connection, got %d runs", stub.conns[1].execs)
+	}
+}
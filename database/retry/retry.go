@@ -0,0 +1,109 @@
+// Package retry retries database work that failed with a transient SQLite
+// or libSQL error. It matches errors without importing a driver, so every
+// backend can use it.
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// baseDelay is the backoff before the second attempt
+	baseDelay = 10 * time.Millisecond
+	// maxDelay caps the backoff between attempts
+	maxDelay = time.Second
+)
+
+// Do calls fn up to attempts times while it fails with a transient error
+// (busy, locked, interrupted or a dropped remote connection), sleeping with
+// exponential backoff and jitter between attempts. Other errors are
+// returned immediately, as is the context's error once ctx is done. fn
+// should run a complete unit of work, such as a whole transaction; a
+// dropped connection is discarded, so the next attempt gets a fresh one.
+func Do(ctx context.Context, attempts int, fn func() error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		// An interrupt caused by cancellation is not worth retrying
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !IsTransient(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
+
+// backoff returns a random delay of up to baseDelay doubled for each
+// previous attempt, capped at maxDelay
+func backoff(attempt int) time.Duration {
+	delay := baseDelay << (attempt - 1)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// IsTransient reports whether err is a busy, locked or interrupted error,
+// or a reset remote connection, that may succeed if the operation is tried
+// again. SQLite errors are recognised by their message, which every driver
+// keeps: go-sqlite3 and go-libsql report sqlite3_errstr, and remote libSQL
+// errors only carry the message.
+func IsTransient(err error) bool {
+	if IsConnReset(err) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"database is locked", "database table is locked", "database is busy", "sqlite_busy", "sqlite_locked", "interrupted"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConnReset reports whether err shows the connection to a remote
+// database was dropped, so the statement may succeed on a new connection
+func IsConnReset(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	// The libSQL client does not always wrap transport errors
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"connection reset", "broken pipe", "stream is closed"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestDo(t *testing.T) {
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Busy on the first two calls, then success
+	calls := 0
+	err := Do(ctx, 5, func() error {
+		calls++
+		if calls < 3 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+
+	// Non-transient errors are not retried
+	calls = 0
+	errPermanent := errors.New("constraint failed")
+	err = Do(ctx, 5, func() error {
+		calls++
+		return errPermanent
+	})
+	if !errors.Is(err, errPermanent) || calls != 1 {
+		t.Errorf("Expected 1 call returning the error, got %d calls and %v", calls, err)
+	}
+
+	// Attempts are bounded
+	calls = 0
+	err = Do(ctx, 3, func() error {
+		calls++
+		return sqlite3.Error{Code: sqlite3.ErrLocked}
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("Expected failure after 3 calls, got %d calls and %v", calls, err)
+	}
+}
+
+func TestDoContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := Do(ctx, 10, func() error {
+		calls++
+		cancel()
+		return errors.New("database is locked")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected no retries after cancellation, got %d calls", calls)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{sqlite3.Error{Code: sqlite3.ErrLocked}, true},
+		{sqlite3.Error{Code: sqlite3.ErrInterrupt}, true},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{errors.New("failed to execute query INSERT: database is locked"), true},
+		{&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}, true},
+		{errors.New("Post \"https://db.turso.io/v2/pipeline\": read tcp: connection reset by peer"), true},
+		{errors.New("stream is closed: driver: bad connection"), true},
+		{errors.New("SQLITE_CONSTRAINT: UNIQUE constraint failed"), false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
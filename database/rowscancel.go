@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"sync/atomic"
+)
+
+// rowsCancelKey is the context key for the timeout released with the rows
+type rowsCancelKey struct{}
+
+// rowsCancel holds the cancel function of a query's default timeout until
+// the rows of the query claim it
+type rowsCancel struct {
+	cancel atomic.Pointer[context.CancelFunc]
+}
+
+// claim returns the cancel function, or nil if another rows has it
+func (r *rowsCancel) claim() context.CancelFunc {
+	if fn := r.cancel.Swap(nil); fn != nil {
+		return *fn
+	}
+	return nil
+}
+
+// withRowsTimeout applies DefaultQueryTimeout like withQueryTimeout for a
+// query whose rows are read after it returns. The timeout cannot end with
+// the call, so it is released when the driver closes the rows, whether by
+// Rows.Close or Row.Scan, instead of lingering until it fires.
+func (db *DB) withRowsTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.cfg.DefaultQueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, db.cfg.DefaultQueryTimeout)
+	holder := &rowsCancel{}
+	holder.cancel.Store(&cancel)
+	return context.WithValue(ctx, rowsCancelKey{}, holder), cancel
+}
+
+// rowsCancelConnector wraps connections so rows from a query begun with
+// withRowsTimeout release its timeout when closed
+type rowsCancelConnector struct {
+	driver.Connector
+}
+
+// Connect implements driver.Connector
+func (c *rowsCancelConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsCancelConn{Conn: conn}, nil
+}
+
+// rowsCancelConn passes every call to the driver connection, wrapping the
+// rows of queries that carry a timeout to release
+type rowsCancelConn struct {
+	driver.Conn
+}
+
+// Unwrap returns the driver connection, for code using sql.Conn.Raw
+func (c *rowsCancelConn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+// QueryContext implements driver.QueryerContext
+func (c *rowsCancelConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if holder, ok := ctx.Value(rowsCancelKey{}).(*rowsCancel); ok {
+		if cancel := holder.claim(); cancel != nil {
+			return &cancelRows{Rows: rows, cancel: cancel}, nil
+		}
+	}
+	return rows, nil
+}
+
+// ExecContext implements driver.ExecerContext
+func (c *rowsCancelConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// PrepareContext implements driver.ConnPrepareContext
+func (c *rowsCancelConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx implements driver.ConnBeginTx
+func (c *rowsCancelConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// CheckNamedValue implements driver.NamedValueChecker
+func (c *rowsCancelConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// Ping implements driver.Pinger
+func (c *rowsCancelConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter
+func (c *rowsCancelConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator
+func (c *rowsCancelConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// cancelRows releases a query's timeout when the rows are closed. The
+// column type methods fall back to what database/sql assumes when the
+// driver rows lack them.
+type cancelRows struct {
+	driver.Rows
+	cancel context.CancelFunc
+}
+
+// Close implements driver.Rows
+func (r *cancelRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// HasNextResultSet implements driver.RowsNextResultSet
+func (r *cancelRows) HasNextResultSet() bool {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.HasNextResultSet()
+	}
+	return false
+}
+
+// NextResultSet implements driver.RowsNextResultSet
+func (r *cancelRows) NextResultSet() error {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.NextResultSet()
+	}
+	return io.EOF
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName
+func (r *cancelRows) ColumnTypeDatabaseTypeName(index int) string {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType
+func (r *cancelRows) ColumnTypeScanType(index int) reflect.Type {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return typed.ColumnTypeScanType(index)
+	}
+	return reflect.TypeFor[any]()
+}
+
+// ColumnTypeNullable implements driver.RowsColumnTypeNullable
+func (r *cancelRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return typed.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+// ColumnTypeLength implements driver.RowsColumnTypeLength
+func (r *cancelRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return typed.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+// ColumnTypePrecisionScale implements driver.RowsColumnTypePrecisionScale
+func (r *cancelRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return typed.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
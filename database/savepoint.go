@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Savepoint marks a point within the transaction that can be rolled back to
+func (tx *Transaction) Savepoint(ctx context.Context, name string) error {
+	return tx.savepointCommand(ctx, "SAVEPOINT", name)
+}
+
+// RollbackTo undoes everything since the named savepoint, which stays open
+func (tx *Transaction) RollbackTo(ctx context.Context, name string) error {
+	return tx.savepointCommand(ctx, "ROLLBACK TO SAVEPOINT", name)
+}
+
+// ReleaseSavepoint keeps the changes made since the named savepoint and
+// removes it
+func (tx *Transaction) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.savepointCommand(ctx, "RELEASE SAVEPOINT", name)
+}
+
+// savepointCommand runs a savepoint statement against a validated name
+func (tx *Transaction) savepointCommand(ctx context.Context, command, name string) error {
+	quoted, err := quoteIdent(name)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, command+" "+quoted); err != nil {
+		return fmt.Errorf("%s %s: %w", command, name, err)
+	}
+	return nil
+}
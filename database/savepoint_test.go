@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSavepoint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "savepoint.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO items (name) VALUES ('kept')"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if err := tx.Savepoint(ctx, "inner"); err != nil {
+		t.Fatalf("Failed to create savepoint: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO items (name) VALUES ('undone')"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if err := tx.RollbackTo(ctx, "inner"); err != nil {
+		t.Fatalf("Failed to roll back to savepoint: %v", err)
+	}
+	if err := tx.ReleaseSavepoint(ctx, "inner"); err != nil {
+		t.Fatalf("Failed to release savepoint: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	count, err := db.Count(ctx, "items", "")
+	if err != nil {
+		t.Fatalf("Failed to count: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row after rolling back to savepoint, got %d", count)
+	}
+
+	// Savepoint names are validated
+	tx, err = db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if err := tx.Savepoint(ctx, "x; DROP TABLE items"); err == nil {
+		t.Error("Expected error for invalid savepoint name, got nil")
+	}
+}
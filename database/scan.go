@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// scannerType is used to detect destinations that scan themselves
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// scanRow scans the current row into dest, which must be a pointer.
+// Structs are filled by matching columns to fields using the `db` tag,
+// falling back to a case-insensitive match on the field name with
+// underscores ignored. Any other destination is scanned directly. Time
+// values are converted to loc.
+func scanRow(rows *sql.Rows, dest any, loc *time.Location) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("scan destination must be a non-nil pointer, got %T", dest)
+	}
+
+	elem := v.Elem()
+	if elem.Kind() != reflect.Struct || v.Type().Implements(scannerType) {
+		if err := rows.Scan(dest); err != nil {
+			return err
+		}
+		localizeTimes([]any{dest}, loc)
+		return nil
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("reading columns: %w", err)
+	}
+
+	targets := make([]any, len(columns))
+	for i, col := range columns {
+		field, ok := fieldForColumn(elem, col)
+		if !ok {
+			return fmt.Errorf("no field for column %q in %s", col, elem.Type())
+		}
+		targets[i] = field.Addr().Interface()
+	}
+
+	if err := rows.Scan(targets...); err != nil {
+		return err
+	}
+	localizeTimes(targets, loc)
+	return nil
+}
+
+// fieldForColumn finds the exported struct field mapped to column
+func fieldForColumn(v reflect.Value, column string) (reflect.Value, bool) {
+	t := v.Type()
+	normalized := strings.ReplaceAll(column, "_", "")
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if tag, ok := f.Tag.Lookup("db"); ok {
+			if tag == column {
+				return v.Field(i), true
+			}
+			continue
+		}
+
+		if strings.EqualFold(f.Name, normalized) {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// MapScan scans the current row into a map keyed by column name. INTEGER,
+// REAL, TEXT, BLOB and NULL values become int64, float64, string, []byte and
+// nil; drivers that return TEXT as bytes are converted using the declared
+// column type.
+func MapScan(rows *sql.Rows) (map[string]any, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("reading column types: %w", err)
+	}
+
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]any, len(columns))
+	for i, col := range columns {
+		row[col] = mapValue(values[i], types[i].DatabaseTypeName())
+	}
+	return row, nil
+}
+
+// MapScanAll scans every remaining row with MapScan and closes rows
+func MapScanAll(rows *sql.Rows) ([]map[string]any, error) {
+	defer rows.Close()
+
+	result := []map[string]any{}
+	for rows.Next() {
+		row, err := MapScan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+	return result, nil
+}
+
+// mapValue converts bytes scanned from a column with TEXT affinity to a
+// string, following SQLite's rules for declared types
+func mapValue(v any, declType string) any {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	upper := strings.ToUpper(declType)
+	if strings.Contains(upper, "CHAR") || strings.Contains(upper, "CLOB") || strings.Contains(upper, "TEXT") {
+		return string(b)
+	}
+	return b
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMapScan(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE mixed (id INTEGER PRIMARY KEY, subject TEXT, score REAL, raw BLOB, note TEXT)",
+		"INSERT INTO mixed (subject, score, raw, note) VALUES ('hello', 1.5, x'00ff', NULL)",
+		"INSERT INTO mixed (subject, score, raw, note) VALUES ('world', 2, NULL, 'seen')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT id, subject, score, raw, note, 1 + 1 AS computed FROM mixed ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		t.Fatalf("Expected a row: %v", rows.Err())
+	}
+	row, err := MapScan(rows)
+	if err != nil {
+		t.Fatalf("Failed to scan row: %v", err)
+	}
+
+	want := map[string]any{
+		"id":       int64(1),
+		"subject":  "hello",
+		"score":    1.5,
+		"raw":      []byte{0x00, 0xff},
+		"note":     nil,
+		"computed": int64(2),
+	}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("Expected %#v, got %#v", want, row)
+	}
+	rows.Close()
+
+	// MapScanAll reads every row
+	rows, err = db.QueryContext(ctx, "SELECT subject, raw FROM mixed ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	all, err := MapScanAll(rows)
+	if err != nil {
+		t.Fatalf("Failed to scan rows: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(all))
+	}
+	if all[1]["subject"] != "world" || all[1]["raw"] != nil {
+		t.Errorf("Expected second row with subject world and NULL raw, got %#v", all[1])
+	}
+}
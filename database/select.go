@@ -0,0 +1,163 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SelectBuilder builds a simple SELECT from a table, quoting the table and
+// column names so they cannot inject SQL. Conditions are written by the
+// caller with ? placeholders. Errors are kept until Build.
+//
+//	query, args, err := Select("id", "subject").
+//		From("emails").
+//		Where("folder = ?", folder).
+//		Where("is_read = ?", false).
+//		OrderBy("received_at DESC", "id DESC").
+//		Limit(50).
+//		Build()
+type SelectBuilder struct {
+	columns []string
+	table   string
+	where   []string
+	args    []any
+	order   []string
+	limit   int
+	offset  int
+	err     error
+}
+
+// Select starts a query for columns, optionally qualified as table.column;
+// no columns selects *
+func Select(columns ...string) *SelectBuilder {
+	b := &SelectBuilder{}
+	for _, column := range columns {
+		if column == "*" {
+			b.columns = append(b.columns, column)
+			continue
+		}
+		quoted, err := quoteColumn(column)
+		if err != nil {
+			b.fail(err)
+			continue
+		}
+		b.columns = append(b.columns, quoted)
+	}
+	return b
+}
+
+// From sets the table to select from
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		b.fail(err)
+		return b
+	}
+	b.table = quoted
+	return b
+}
+
+// Where adds a condition with its arguments. Conditions from several calls
+// must all hold; an empty condition, such as Keyset.Where returns for the
+// first page, is ignored.
+func (b *SelectBuilder) Where(cond string, args ...any) *SelectBuilder {
+	if cond == "" {
+		return b
+	}
+	b.where = append(b.where, "("+cond+")")
+	b.args = append(b.args, args...)
+	return b
+}
+
+// OrderBy adds sort columns, each optionally followed by ASC or DESC
+func (b *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	for _, column := range columns {
+		fields := strings.Fields(column)
+		direction := "ASC"
+		switch {
+		case len(fields) == 2 && strings.EqualFold(fields[1], "DESC"):
+			direction = "DESC"
+		case len(fields) == 2 && strings.EqualFold(fields[1], "ASC"):
+		case len(fields) != 1:
+			b.fail(fmt.Errorf("invalid sort column %q", column))
+			continue
+		}
+		quoted, err := quoteColumn(fields[0])
+		if err != nil {
+			b.fail(err)
+			continue
+		}
+		b.order = append(b.order, quoted+" "+direction)
+	}
+	return b
+}
+
+// Limit caps the number of rows returned; 0 means no limit
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	if n < 0 {
+		b.fail(fmt.Errorf("negative limit %d", n))
+		return b
+	}
+	b.limit = n
+	return b
+}
+
+// Offset skips the first n rows; it requires a limit
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	if n < 0 {
+		b.fail(fmt.Errorf("negative offset %d", n))
+		return b
+	}
+	b.offset = n
+	return b
+}
+
+// Build returns the query and its arguments, or the first error from
+// building it
+func (b *SelectBuilder) Build() (string, []any, error) {
+	if b.err != nil {
+		return "", nil, fmt.Errorf("building select: %w", b.err)
+	}
+	if b.table == "" {
+		return "", nil, fmt.Errorf("building select: no table")
+	}
+	if b.offset > 0 && b.limit == 0 {
+		return "", nil, fmt.Errorf("building select: offset without limit")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	if len(b.columns) == 0 {
+		sb.WriteString("*")
+	} else {
+		sb.WriteString(strings.Join(b.columns, ", "))
+	}
+	sb.WriteString(" FROM ")
+	sb.WriteString(b.table)
+
+	args := append([]any(nil), b.args...)
+	if len(b.where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(b.where, " AND "))
+	}
+	if len(b.order) > 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(b.order, ", "))
+	}
+	if b.limit > 0 {
+		sb.WriteString(" LIMIT ?")
+		args = append(args, b.limit)
+		if b.offset > 0 {
+			sb.WriteString(" OFFSET ?")
+			args = append(args, b.offset)
+		}
+	}
+	return sb.String(), args, nil
+}
+
+// fail records err unless an earlier error was recorded
+func (b *SelectBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectBuild(t *testing.T) {
+	tests := []struct {
+		name  string
+		b     *SelectBuilder
+		query string
+		args  []any
+	}{
+		{
+			name:  "no where",
+			b:     Select().From("emails"),
+			query: `SELECT * FROM "emails"`,
+		},
+		{
+			name:  "columns",
+			b:     Select("id", "emails.subject").From("emails"),
+			query: `SELECT "id", "emails"."subject" FROM "emails"`,
+		},
+		{
+			name:  "where",
+			b:     Select("id").From("emails").Where("folder = ?", "inbox").Where("is_read = ? OR starred = ?", 0, 1),
+			query: `SELECT "id" FROM "emails" WHERE (folder = ?) AND (is_read = ? OR starred = ?)`,
+			args:  []any{"inbox", 0, 1},
+		},
+		{
+			name:  "empty where",
+			b:     Select("id").From("emails").Where(""),
+			query: `SELECT "id" FROM "emails"`,
+		},
+		{
+			name:  "order and limit",
+			b:     Select("id").From("emails").Where("folder = ?", "inbox").OrderBy("received_at DESC", "id").Limit(50).Offset(100),
+			query: `SELECT "id" FROM "emails" WHERE (folder = ?) ORDER BY "received_at" DESC, "id" ASC LIMIT ? OFFSET ?`,
+			args:  []any{"inbox", 50, 100},
+		},
+	}
+	for _, tt := range tests {
+		query, args, err := tt.b.Build()
+		if err != nil {
+			t.Errorf("%s: failed to build: %v", tt.name, err)
+			continue
+		}
+		if query != tt.query {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.query, query)
+		}
+		if len(args) != 0 || len(tt.args) != 0 {
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("%s: expected args %v, got %v", tt.name, tt.args, args)
+			}
+		}
+	}
+}
+
+func TestSelectBuildErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *SelectBuilder
+	}{
+		{"no table", Select("id")},
+		{"bad table", Select().From("emails; DROP TABLE emails")},
+		{"bad column", Select("id, password").From("emails")},
+		{"bad order", Select().From("emails").OrderBy("id DESC; --")},
+		{"offset without limit", Select().From("emails").Offset(10)},
+	}
+	for _, tt := range tests {
+		if _, _, err := tt.b.Build(); err == nil {
+			t.Errorf("%s: expected error, got nil", tt.name)
+		}
+	}
+}
+
+func TestSelectQuery(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, folder TEXT, subject TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO emails (folder, subject) VALUES ('inbox', 'a'), ('inbox', 'b'), ('spam', 'c'), ('inbox', 'd')")
+	if err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	query, args, err := Select("subject").From("emails").Where("folder = ?", "inbox").OrderBy("id DESC").Limit(2).Build()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	var subjects []string
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			t.Fatalf("Failed to scan row: %v", err)
+		}
+		subjects = append(subjects, subject)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+	if !reflect.DeepEqual(subjects, []string{"d", "b"}) {
+		t.Errorf("Expected [d b], got %v", subjects)
+	}
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// startWriter reserves the connection used for serialized writes
+func (db *DB) startWriter(ctx context.Context) error {
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("reserving write connection: %w", err)
+	}
+	db.writer = make(chan *sql.Conn, 1)
+	db.writer <- conn
+	return nil
+}
+
+// stopWriter closes the write connection if it is not handed out. A
+// connection in use is closed along with the pool once it is returned.
+func (db *DB) stopWriter() {
+	if db.writer == nil {
+		return
+	}
+	select {
+	case conn := <-db.writer:
+		conn.Close()
+		// Put it back so later writes fail with sql.ErrConnDone instead of waiting
+		db.writer <- conn
+	default:
+	}
+}
+
+// acquireWriter waits for the write connection, in the order callers queue
+// on the channel, or until ctx is done
+func (db *DB) acquireWriter(ctx context.Context) (*sql.Conn, error) {
+	select {
+	case conn := <-db.writer:
+		return conn, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for write connection: %w", ctx.Err())
+	}
+}
+
+// exec runs a statement, on the write connection if writes are serialized
+// and the statement is not a plain read
+func (db *DB) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if db.writer == nil || isReadQuery(query) {
+		return db.DB.ExecContext(ctx, query, args...)
+	}
+
+	conn, err := db.acquireWriter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { db.writer <- conn }()
+
+	return conn.ExecContext(ctx, query, args...)
+}
+
+// openSerializedTx begins a write transaction that holds the write
+// connection until it commits or rolls back
+func (db *DB) openSerializedTx(ctx context.Context, mode TxMode) (*Transaction, error) {
+	conn, err := db.acquireWriter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN "+mode.String()); err != nil {
+		db.writer <- conn
+		return nil, fmt.Errorf("beginning %s transaction: %w", mode, err)
+	}
+
+	db.activeTx.Add(1)
+	return &Transaction{conn: conn, db: db, release: func() { db.writer <- conn }}, nil
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSerializeWrites(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "serialized.db")
+	cfg.MaxOpenConns = 10
+	cfg.SerializeWrites = true
+	// Without a busy_timeout any contention fails immediately
+	cfg.Pragmas = DefaultPragmas()
+	cfg.Pragmas["busy_timeout"] = "0"
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE writes (id INTEGER PRIMARY KEY, value INTEGER)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Mix transactions holding the lock with single-statement writes
+	const workers = 50
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if id%2 == 0 {
+				_, err := db.ExecContext(ctx, "INSERT INTO writes (value) VALUES (?)", id)
+				errs <- err
+				return
+			}
+			errs <- func() error {
+				tx, err := db.BeginTxMode(ctx, TxImmediate, nil)
+				if err != nil {
+					return err
+				}
+				defer tx.Rollback()
+
+				if _, err := tx.Exec("INSERT INTO writes (value) VALUES (?)", id); err != nil {
+					return err
+				}
+				time.Sleep(time.Millisecond)
+				return tx.Commit()
+			}()
+		}(w)
+	}
+
+	// Reads keep using the pool while writes queue
+	var count int64
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM writes").Scan(&count); err != nil {
+		t.Errorf("Failed to read during writes: %v", err)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected no write errors, got %v", err)
+		}
+	}
+
+	count, err = db.Count(ctx, "writes", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != workers {
+		t.Errorf("Expected %d rows, got %d", workers, count)
+	}
+
+	// The write connection is idle again, so shutdown does not wait on it
+	if n := db.inUse(); n != 0 {
+		t.Errorf("Expected no connections in use, got %d", n)
+	}
+}
+
+func TestSerializeWritesMemory(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SerializeWrites = true
+
+	if _, err := Open(cfg); err == nil {
+		t.Error("Expected error serializing writes to an in-memory database, got nil")
+	}
+}
+
+func TestSerializedTxRollbackAfterCommit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "serialized.db")
+	cfg.SerializeWrites = true
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE writes (id INTEGER PRIMARY KEY, value INTEGER)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	first, err := db.BeginTxMode(ctx, TxImmediate, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if err := first.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	// The write connection now belongs to the second transaction, which a
+	// late rollback of the first must not touch
+	second, err := db.BeginTxMode(ctx, TxImmediate, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if err := first.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("Expected sql.ErrTxDone, got %v", err)
+	}
+	if _, err := first.Exec("INSERT INTO writes (value) VALUES (1)"); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("Expected sql.ErrTxDone, got %v", err)
+	}
+	var n int
+	if err := first.QueryRow("SELECT COUNT(*) FROM writes").Scan(&n); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("Expected sql.ErrTxDone from QueryRow, got %v", err)
+	}
+	if _, err := first.PrepareContext(ctx, "SELECT 1"); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("Expected sql.ErrTxDone from Prepare, got %v", err)
+	}
+
+	if _, err := second.Exec("INSERT INTO writes (value) VALUES (2)"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if err := second.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	count, err := db.Count(ctx, "writes", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row, got %d", count)
+	}
+}
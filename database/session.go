@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Session is a handle bound to a single connection from the pool. Use it for
+// connection-scoped state such as attached databases, temp tables and
+// pragmas, which would otherwise be lost between pooled statements.
+type Session struct {
+	conn *sql.Conn
+	db   *DB
+}
+
+// Session reserves a connection from the pool until Close is called. With
+// SplitReadWrite it holds the single write connection, blocking every other
+// writer, so keep sessions short there.
+func (db *DB) Session(ctx context.Context) (*Session, error) {
+	if db.closing.Load() {
+		return nil, ErrShutdown
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection: %w", err)
+	}
+	return &Session{conn: conn, db: db}, nil
+}
+
+// ExecContext executes a query on the session connection
+func (s *Session) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	res, err := s.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, statementError(query, err)
+	}
+	return res, nil
+}
+
+// QueryContext runs a query on the session connection
+func (s *Session) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, statementError(query, err)
+	}
+	return rows, nil
+}
+
+// QueryRowContext runs a query returning at most one row on the session connection
+func (s *Session) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.conn.QueryRowContext(ctx, query, args...)
+}
+
+// CreateTempTable creates a TEMP table with the given column definitions,
+// such as "id INTEGER PRIMARY KEY, subject TEXT", visible only on the
+// session connection. Use it to stage a batch before merging it into the
+// main tables. The returned cleanup drops the table; closing the session
+// without it leaves the table on the pooled connection.
+func (s *Session) CreateTempTable(ctx context.Context, name, schema string) (cleanup func() error, err error) {
+	quoted, err := quoteIdent(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.ExecContext(ctx, fmt.Sprintf("CREATE TEMP TABLE %s (%s)", quoted, schema)); err != nil {
+		return nil, fmt.Errorf("creating temp table %s: %w", name, err)
+	}
+
+	cleanup = func() error {
+		if _, err := s.conn.ExecContext(context.Background(), "DROP TABLE IF EXISTS temp."+quoted); err != nil {
+			return fmt.Errorf("dropping temp table %s: %w", name, err)
+		}
+		return nil
+	}
+	return cleanup, nil
+}
+
+// BeginTx starts a new transaction on the session connection
+func (s *Session) BeginTx(ctx context.Context) (*Transaction, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	s.db.activeTx.Add(1)
+	return &Transaction{tx: tx, db: s.db}, nil
+}
+
+// Close returns the connection to the pool
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
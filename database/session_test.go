@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	// Use a file database so every connection sees the same main schema
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "session.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := db.Session(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start session: %v", err)
+	}
+	defer session.Close()
+
+	_, err = session.ExecContext(ctx, "CREATE TEMP TABLE staging (id INTEGER PRIMARY KEY, value TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create temp table: %v", err)
+	}
+
+	// Writes in a session transaction are visible to the session
+	tx, err := session.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO staging (value) VALUES (?)", "staged"); err != nil {
+		t.Fatalf("Failed to insert in transaction: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	var value string
+	err = session.QueryRowContext(ctx, "SELECT value FROM staging WHERE id = 1").Scan(&value)
+	if err != nil {
+		t.Fatalf("Failed to query temp table in session: %v", err)
+	}
+	if value != "staged" {
+		t.Errorf("Expected 'staged', got '%s'", value)
+	}
+
+	// A fresh session uses a different connection and cannot see the table
+	other, err := db.Session(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start second session: %v", err)
+	}
+	defer other.Close()
+
+	if _, err := other.QueryContext(ctx, "SELECT * FROM staging"); err == nil {
+		t.Error("Expected temp table to be invisible to another session")
+	}
+}
+
+func TestCreateTempTable(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "staging.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE folders (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO folders (id, name) VALUES (1, 'inbox'), (2, 'archive')",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	session, err := db.Session(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start session: %v", err)
+	}
+	defer session.Close()
+
+	cleanup, err := session.CreateTempTable(ctx, "incoming", "subject TEXT, folder_id INTEGER")
+	if err != nil {
+		t.Fatalf("Failed to create temp table: %v", err)
+	}
+
+	_, err = session.ExecContext(ctx, "INSERT INTO incoming (subject, folder_id) VALUES (?, ?), (?, ?)", "hello", 1, "old", 2)
+	if err != nil {
+		t.Fatalf("Failed to stage rows: %v", err)
+	}
+
+	// The staged rows join against the real table
+	var name string
+	err = session.QueryRowContext(ctx,
+		"SELECT f.name FROM incoming i JOIN folders f ON f.id = i.folder_id WHERE i.subject = ?", "old").Scan(&name)
+	if err != nil {
+		t.Fatalf("Failed to join staged rows: %v", err)
+	}
+	if name != "archive" {
+		t.Errorf("Expected archive, got %q", name)
+	}
+
+	if err := cleanup(); err != nil {
+		t.Fatalf("Failed to clean up: %v", err)
+	}
+
+	var count int
+	err = session.QueryRowContext(ctx, "SELECT COUNT(*) FROM temp.sqlite_master WHERE name = 'incoming'").Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query temp schema: %v", err)
+	}
+	if count != 0 {
+		t.Error("Expected temp table to be dropped")
+	}
+
+	if _, err := session.CreateTempTable(ctx, "bad name", "id INTEGER"); err == nil {
+		t.Error("Expected error for invalid table name, got nil")
+	}
+}
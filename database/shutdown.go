@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrShutdown is returned for operations started after Shutdown was called
+var ErrShutdown = errors.New("database is shutting down")
+
+// errConnector fails every connection attempt with err
+type errConnector struct {
+	err error
+}
+
+// Connect implements driver.Connector
+func (c errConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+// Driver implements driver.Connector
+func (c errConnector) Driver() driver.Driver {
+	return nil
+}
+
+// errRow returns a row whose Err and Scan report err, for refusing a
+// QueryRow call, which has no error result of its own
+func errRow(err error) *sql.Row {
+	db := sql.OpenDB(errConnector{err: err})
+	defer db.Close()
+	return db.QueryRow("SELECT 1")
+}
+
+// shutdownPollInterval is how often Shutdown checks for in-flight queries
+const shutdownPollInterval = 10 * time.Millisecond
+
+// Shutdown stops accepting new operations, waits for in-flight queries and
+// transactions to finish, checkpoints the WAL of file databases and closes
+// the pools. If ctx expires first the pools are closed anyway and an error
+// reports how many connections were still busy.
+func (db *DB) Shutdown(ctx context.Context) error {
+	db.closing.Store(true)
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for {
+		busy := db.inUse()
+		if busy == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return fmt.Errorf("shutdown interrupted %d in-flight connections: %w", busy, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	// Fold the WAL back into the main database so the files are self-contained
+	if !isRemote(db.cfg.Path) && !isMemory(db.cfg.Path) {
+		if _, err := db.DB.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+			db.Close()
+			return fmt.Errorf("checkpointing WAL: %w", err)
+		}
+	}
+
+	return db.Close()
+}
+
+// inUse returns the number of connections currently checked out of the pools
+func (db *DB) inUse() int {
+	n := db.DB.Stats().InUse
+	if db.reader != nil {
+		n += db.reader.Stats().InUse
+	}
+	// The serialized write connection is only busy while it is handed out
+	if db.writer != nil && len(db.writer) == 1 {
+		n--
+	}
+	return n
+}
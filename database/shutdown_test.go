@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForQueries(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "shutdown.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE shutdown_test (id INTEGER PRIMARY KEY, value TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO shutdown_test (value) VALUES (?)", "pending"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	// Start a slow query and give it time to claim a connection
+	finished := make(chan time.Time, 1)
+	go func() {
+		var n int
+		if err := db.QueryRow(slowQuery).Scan(&n); err != nil {
+			t.Errorf("Slow query failed: %v", err)
+		}
+		finished <- time.Now()
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := WithContext(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := db.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+	stopped := time.Now()
+
+	if done := <-finished; stopped.Before(done) {
+		t.Errorf("Expected Shutdown to wait for the slow query")
+	}
+
+	// New operations are rejected
+	if _, err := db.Exec("INSERT INTO shutdown_test (value) VALUES (?)", "late"); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Expected ErrShutdown, got %v", err)
+	}
+	if _, err := db.BeginTx(context.Background()); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Expected ErrShutdown beginning a transaction, got %v", err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM shutdown_test").Scan(&n); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Expected ErrShutdown from QueryRow, got %v", err)
+	}
+
+	// The final checkpoint leaves an empty WAL
+	if info, err := os.Stat(cfg.Path + "-wal"); err == nil && info.Size() != 0 {
+		t.Errorf("Expected WAL to be checkpointed, got %d bytes", info.Size())
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "shutdown.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Hold a connection open for longer than the shutdown deadline
+	tx, err := db.BeginTx(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	ctx, cancel := WithContext(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = db.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded error, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// snapshotManifestFile lists the snapshots in a snapshot directory
+const snapshotManifestFile = "snapshots.json"
+
+// snapshotLabelPattern matches labels that are safe to use in file names
+var snapshotLabelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+var (
+	// ErrSnapshotExists is returned by Snapshot when the label is taken
+	ErrSnapshotExists = errors.New("snapshot already exists")
+	// ErrSnapshotNotFound is returned by RestoreSnapshot for an unknown label
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+)
+
+// SnapshotInfo describes a snapshot taken with Snapshot
+type SnapshotInfo struct {
+	Label         string    `json:"label"`
+	File          string    `json:"file"`
+	Created       time.Time `json:"created"`
+	SchemaVersion int64     `json:"schema_version"` // Migration version, or user_version without migrations
+}
+
+// snapshotDir returns where snapshots of the database are kept, next to the
+// database file unless SnapshotDir is set
+func (db *DB) snapshotDir() (string, error) {
+	if isMemory(db.cfg.Path) || isRemote(db.cfg.Path) {
+		return "", fmt.Errorf("snapshots require a local file database")
+	}
+	if db.cfg.SnapshotDir != "" {
+		return db.cfg.SnapshotDir, nil
+	}
+	return filePath(db.cfg.Path) + ".snapshots", nil
+}
+
+// Snapshot saves a copy of the database labelled label, for example before
+// a risky operation, and returns its path. The copy is written with VACUUM
+// INTO, so it is consistent while the database is in use. Labels may
+// contain letters, digits, dots, dashes and underscores, and must be unique.
+func (db *DB) Snapshot(ctx context.Context, label string) (string, error) {
+	if !snapshotLabelPattern.MatchString(label) {
+		return "", fmt.Errorf("taking snapshot: invalid label %q", label)
+	}
+
+	dir, err := db.snapshotDir()
+	if err != nil {
+		return "", fmt.Errorf("taking snapshot: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating snapshot directory: %w", err)
+	}
+
+	snapshots, err := readSnapshotManifest(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range snapshots {
+		if s.Label == label {
+			return "", fmt.Errorf("taking snapshot %s: %w", label, ErrSnapshotExists)
+		}
+	}
+
+	version, err := db.schemaVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	info := SnapshotInfo{
+		Label:         label,
+		File:          label + ".db",
+		Created:       time.Now().UTC(),
+		SchemaVersion: version,
+	}
+	path := filepath.Join(dir, info.File)
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return "", fmt.Errorf("taking snapshot %s: %w", label, err)
+	}
+
+	snapshots = append(snapshots, info)
+	if err := writeSnapshotManifest(dir, snapshots); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// ListSnapshots returns the snapshots of the database, oldest first
+func (db *DB) ListSnapshots() ([]SnapshotInfo, error) {
+	dir, err := db.snapshotDir()
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	return readSnapshotManifest(dir)
+}
+
+// RestoreSnapshot replaces the contents of the database with the snapshot
+// labelled label. The pages are copied into the open database with the
+// SQLite online backup API, so other connections see the restored state on
+// their next statement. It is refused while a transaction is open.
+func (db *DB) RestoreSnapshot(ctx context.Context, label string) error {
+	if db.activeTx.Load() > 0 {
+		return fmt.Errorf("restoring snapshot: %w", ErrTransactionOpen)
+	}
+
+	dir, err := db.snapshotDir()
+	if err != nil {
+		return fmt.Errorf("restoring snapshot: %w", err)
+	}
+	snapshots, err := readSnapshotManifest(dir)
+	if err != nil {
+		return err
+	}
+	var path string
+	for _, s := range snapshots {
+		if s.Label == label {
+			path = filepath.Join(dir, s.File)
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("restoring snapshot %s: %w", label, ErrSnapshotNotFound)
+	}
+
+	srcConn, err := (&sqlite3.SQLiteDriver{}).Open("file:" + path + "?mode=ro")
+	if err != nil {
+		return fmt.Errorf("opening snapshot %s: %w", label, err)
+	}
+	defer srcConn.Close()
+
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		dest, ok := sqliteConn(driverConn)
+		if !ok {
+			return fmt.Errorf("unsupported driver connection %T", driverConn)
+		}
+		return copyPages(ctx, dest, srcConn.(*sqlite3.SQLiteConn))
+	})
+	if err != nil {
+		return fmt.Errorf("restoring snapshot %s: %w", label, err)
+	}
+
+	db.InvalidateCache()
+	return nil
+}
+
+// schemaVersion returns the migration version recorded by the migrations
+// package, or PRAGMA user_version if the database has no migration table
+func (db *DB) schemaVersion(ctx context.Context) (int64, error) {
+	var migrated bool
+	err := db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')").Scan(&migrated)
+	if err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+
+	query := "PRAGMA user_version"
+	if migrated {
+		query = "SELECT COALESCE(MAX(version), 0) FROM schema_migrations"
+	}
+
+	var version int64
+	if err := db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return version, nil
+}
+
+// readSnapshotManifest loads the snapshots recorded in dir
+func readSnapshotManifest(dir string) ([]SnapshotInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []SnapshotInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot manifest: %w", err)
+	}
+
+	var snapshots []SnapshotInfo
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, fmt.Errorf("parsing snapshot manifest: %w", err)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// writeSnapshotManifest replaces the snapshot manifest of dir
+func writeSnapshotManifest(dir string, snapshots []SnapshotInfo) error {
+	data, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding snapshot manifest: %w", err)
+	}
+	return writeBackupFile(dir, snapshotManifestFile, data, "snapshot manifest")
+}
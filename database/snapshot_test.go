@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "emails.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT)",
+		"INSERT INTO emails (subject) VALUES ('keep'), ('also keep')",
+		"PRAGMA user_version = 3",
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	path, err := db.Snapshot(ctx, "before-purge")
+	if err != nil {
+		t.Fatalf("Failed to take snapshot: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected snapshot file at %s: %v", path, err)
+	}
+
+	// Labels are unique and must be safe file names
+	if _, err := db.Snapshot(ctx, "before-purge"); !errors.Is(err, ErrSnapshotExists) {
+		t.Errorf("Expected ErrSnapshotExists, got %v", err)
+	}
+	if _, err := db.Snapshot(ctx, "../escape"); err == nil {
+		t.Error("Expected error for invalid label, got nil")
+	}
+
+	snapshots, err := db.ListSnapshots()
+	if err != nil {
+		t.Fatalf("Failed to list snapshots: %v", err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("Expected 1 snapshot, got %d", len(snapshots))
+	}
+	if s := snapshots[0]; s.Label != "before-purge" || s.SchemaVersion != 3 || s.Created.IsZero() {
+		t.Errorf("Expected labelled snapshot at version 3, got %+v", s)
+	}
+
+	// The risky operation
+	if _, err := db.ExecContext(ctx, "DELETE FROM emails"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	if err := db.RestoreSnapshot(ctx, "before-purge"); err != nil {
+		t.Fatalf("Failed to restore snapshot: %v", err)
+	}
+
+	count, err := db.Count(ctx, "emails", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows after restore, got %d", count)
+	}
+
+	if err := db.RestoreSnapshot(ctx, "missing"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("Expected ErrSnapshotNotFound, got %v", err)
+	}
+}
+
+func TestSnapshotDSN(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Path = "file:" + filepath.Join(dir, "emails.db") + "?mode=rwc&_txlock=immediate"
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Snapshots are kept next to the database file, not the DSN
+	path, err := db.Snapshot(ctx, "first")
+	if err != nil {
+		t.Fatalf("Failed to take snapshot: %v", err)
+	}
+	if want := filepath.Join(dir, "emails.db.snapshots", "first.db"); path != want {
+		t.Errorf("Expected snapshot at %s, got %s", want, path)
+	}
+
+	mem, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer mem.Close()
+	if _, err := mem.Snapshot(ctx, "first"); err == nil {
+		t.Error("Expected error taking a snapshot of an in-memory database, got nil")
+	}
+	if _, err := mem.ListSnapshots(); err == nil {
+		t.Error("Expected error listing snapshots of an in-memory database, got nil")
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"emails.db", "emails.db"},
+		{"/data/emails.db", "/data/emails.db"},
+		{"file:emails.db?mode=rwc", "emails.db"},
+		{"file:/data/emails.db?mode=ro&cache=shared", "/data/emails.db"},
+		{"file:///data/my%20emails.db", "/data/my emails.db"},
+		{"file://localhost/data/emails.db", "/data/emails.db"},
+	}
+	for _, tt := range tests {
+		if got := filePath(tt.path); got != tt.want {
+			t.Errorf("filePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
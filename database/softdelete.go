@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SoftDelete marks the rows of table matching where as deleted by setting
+// their deleted_at column, and returns the number of rows marked. Rows that
+// are already soft-deleted keep their original deleted_at.
+func (db *DB) SoftDelete(ctx context.Context, table string, where string, args ...any) (int64, error) {
+	return db.setDeletedAt(ctx, table, "CURRENT_TIMESTAMP", "deleted_at IS NULL", where, args)
+}
+
+// Restore clears deleted_at on the soft-deleted rows of table matching where
+func (db *DB) Restore(ctx context.Context, table string, where string, args ...any) (int64, error) {
+	return db.setDeletedAt(ctx, table, "NULL", "deleted_at IS NOT NULL", where, args)
+}
+
+// QueryActive selects the rows of table matching the optional where clause,
+// hiding soft-deleted rows
+func (db *DB) QueryActive(ctx context.Context, table string, where string, args ...any) (*sql.Rows, error) {
+	return db.selectFrom(ctx, table, ActiveWhere(where), args)
+}
+
+// QueryWithDeleted selects the rows of table matching the optional where
+// clause, including soft-deleted rows
+func (db *DB) QueryWithDeleted(ctx context.Context, table string, where string, args ...any) (*sql.Rows, error) {
+	return db.selectFrom(ctx, table, where, args)
+}
+
+// ActiveWhere restricts a where clause, which may be empty, to rows that
+// have not been soft-deleted
+func ActiveWhere(where string) string {
+	if where == "" {
+		return "deleted_at IS NULL"
+	}
+	return "(" + where + ") AND deleted_at IS NULL"
+}
+
+// setDeletedAt sets deleted_at to value on rows matching both conditions
+func (db *DB) setDeletedAt(ctx context.Context, table, value, state, where string, args []any) (int64, error) {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "UPDATE " + quoted + " SET deleted_at = " + value + " WHERE " + state
+	if where != "" {
+		query += " AND (" + where + ")"
+	}
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("updating deleted_at in %s: %w", table, err)
+	}
+	return res.RowsAffected()
+}
+
+// selectFrom selects all columns of table matching the optional where clause
+func (db *DB) selectFrom(ctx context.Context, table, where string, args []any) (*sql.Rows, error) {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + quoted
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("selecting from %s: %w", table, err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// subjects collects the subject column of each row
+func subjects(t *testing.T, rows *sql.Rows) []string {
+	t.Helper()
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var e struct {
+			ID        int64
+			Subject   string
+			DeletedAt sql.NullTime `db:"deleted_at"`
+		}
+		if err := scanRow(rows, &e, time.UTC); err != nil {
+			t.Fatalf("Failed to scan row: %v", err)
+		}
+		out = append(out, e.Subject)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to iterate rows: %v", err)
+	}
+	return out
+}
+
+func TestSoftDelete(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statements := []string{
+		`CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT, deleted_at TIMESTAMP)`,
+		`INSERT INTO emails (subject) VALUES ('welcome'), ('invoice'), ('newsletter')`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to set up: %v", err)
+		}
+	}
+
+	n, err := db.SoftDelete(ctx, "emails", "subject = ? OR subject = ?", "invoice", "newsletter")
+	if err != nil {
+		t.Fatalf("Failed to soft delete: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 rows soft-deleted, got %d", n)
+	}
+
+	// Rows are still stored but hidden by default
+	if count, _ := db.Count(ctx, "emails", ""); count != 3 {
+		t.Errorf("Expected 3 stored rows, got %d", count)
+	}
+
+	rows, err := db.QueryActive(ctx, "emails", "")
+	if err != nil {
+		t.Fatalf("Failed to query active rows: %v", err)
+	}
+	if got := subjects(t, rows); len(got) != 1 || got[0] != "welcome" {
+		t.Errorf("Expected only 'welcome', got %v", got)
+	}
+
+	// The where clause is grouped so OR cannot bypass the filter
+	rows, err = db.QueryActive(ctx, "emails", "subject = ? OR subject = ?", "welcome", "invoice")
+	if err != nil {
+		t.Fatalf("Failed to query active rows: %v", err)
+	}
+	if got := subjects(t, rows); len(got) != 1 {
+		t.Errorf("Expected 1 active match, got %v", got)
+	}
+
+	rows, err = db.QueryWithDeleted(ctx, "emails", "")
+	if err != nil {
+		t.Fatalf("Failed to query all rows: %v", err)
+	}
+	if got := subjects(t, rows); len(got) != 3 {
+		t.Errorf("Expected 3 rows including deleted, got %v", got)
+	}
+
+	// Deleted rows can be recovered
+	n, err = db.Restore(ctx, "emails", "subject = ?", "invoice")
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 row restored, got %d", n)
+	}
+	rows, err = db.QueryActive(ctx, "emails", "")
+	if err != nil {
+		t.Fatalf("Failed to query active rows: %v", err)
+	}
+	if got := subjects(t, rows); len(got) != 2 {
+		t.Errorf("Expected 2 active rows after restore, got %v", got)
+	}
+}
@@ -6,15 +6,20 @@ import (
 	"context"
 	"database/sql"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
-	// sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
 	_ "github.com/knaka/go-sqlite3-fts5"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/parsel-email/lib-go/database/internal/sqlutil"
 )
 
+// DriverName is the database/sql driver used by Open, matching the name
+// reported by the database package for the same backend
+const DriverName = "sqlite3"
+
 // Config holds database configuration
 type Config struct {
 	Path            string
@@ -24,8 +29,17 @@ type Config struct {
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
 	Pragmas         Pragmas
+	EnableVec       bool // Load sqlite-vec; requires building with the vec tag
+
+	// Extensions are paths to loadable extensions, such as spellfix or a
+	// custom tokenizer, loaded into every connection
+	Extensions []string
 }
 
+// ErrVecUnavailable is returned by Open when EnableVec is set but the
+// package was built without the vec build tag
+var ErrVecUnavailable = errors.New("sqlite-vec not available: build with -tags vec")
+
 // DefaultConfig returns a default database configuration
 func DefaultConfig() Config {
 	return Config{
@@ -59,10 +73,20 @@ func Open(cfg Config) (*sql.DB, error) {
 		dsn += "?_fts5=1&_json=1"
 	}
 
-	// sqlite_vec.Auto()
-	db, err := sql.Open("sqlite3", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("opening database: %w", err)
+	if cfg.EnableVec {
+		if err := enableVec(); err != nil {
+			return nil, fmt.Errorf("enabling sqlite-vec: %w", err)
+		}
+	}
+
+	if len(cfg.Extensions) > 0 {
+		db = sql.OpenDB(newExtensionConnector(dsn, cfg.Extensions))
+	} else {
+		var err error
+		db, err = sql.Open(DriverName, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("opening database: %w", err)
+		}
 	}
 
 	if db == nil {
@@ -84,6 +108,16 @@ func Open(cfg Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// InsertGetID runs sqlutil.InsertGetID on a connection from db
+func InsertGetID(ctx context.Context, db *sql.DB, query string, args ...any) (int64, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+	return sqlutil.InsertGetID(ctx, conn, query, args...)
+}
+
 // WithContext returns a context with timeout for database operations
 func WithContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout > 0 {
@@ -92,6 +126,16 @@ func WithContext(ctx context.Context, timeout time.Duration) (context.Context, c
 	return ctx, func() {}
 }
 
+// SerializeFloat32 serializes float32 values into the little-endian BLOB
+// format used by sqlite-vec
+func SerializeFloat32(vector []float32) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, vector); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // DeserializeFloat32 deserializes a byte slice into a slice of float32 values
 // written until sqllite-vec supports deserialization method
 // https://github.com/asg017/sqlite-vec/issues/171
@@ -112,3 +156,13 @@ func DeserializeFloat32(data []byte) ([]float32, error) {
 
 	return vector, nil
 }
+
+// ServerVersion returns the SQLite version of the connected database. Features such as
+// RETURNING (3.35) can be gated on it.
+func ServerVersion(ctx context.Context, db *sql.DB) (string, error) {
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("reading server version: %w", err)
+	}
+	return version, nil
+}
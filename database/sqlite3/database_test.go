@@ -2,14 +2,17 @@ package sqlite3
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
 	// sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
+	"github.com/parsel-email/lib-go/database/retry"
 )
 
 func TestDatabaseBasic(t *testing.T) {
@@ -456,71 +459,21 @@ func TestConnectionPool(t *testing.T) {
 			// Create a context with timeout
 			ctx, cancel := WithContext(context.Background(), 5*time.Second)
 
-			// Begin transaction with retry
-			var tx *sql.Tx
-			var err error
-
-			// Try up to 3 times to begin a transaction
-			for attempt := 0; attempt < 3; attempt++ {
-				tx, err = db.BeginTx(ctx, nil)
-				if err == nil {
-					break
-				}
-
-				// If failed, wait a bit before retrying
-				time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
-			}
-
-			if err != nil {
-				errChan <- fmt.Errorf("worker %d failed to begin transaction after retries: %w", id, err)
-				cancel()
-				doneChan <- true
-				return
-			}
-
-			// Insert data with retry
+			// Run the whole transaction again if it hits a lock
 			value := fmt.Sprintf("worker %d - iter %d", id, i)
-			var execErr error
-
-			// Try up to 3 times to execute the query
-			for attempt := 0; attempt < 3; attempt++ {
-				_, execErr = tx.Exec("INSERT INTO pool_test (value) VALUES (?)", value)
-				if execErr == nil {
-					break
+			err := retry.Do(ctx, 5, func() error {
+				tx, err := db.BeginTx(ctx, nil)
+				if err != nil {
+					return err
 				}
-
-				// If failed and not on last attempt, wait before retrying
-				if attempt < 2 {
-					time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
+				if _, err := tx.Exec("INSERT INTO pool_test (value) VALUES (?)", value); err != nil {
+					tx.Rollback()
+					return err
 				}
-			}
-
-			if execErr != nil {
-				tx.Rollback()
-				errChan <- fmt.Errorf("worker %d failed to insert after retries: %w", id, execErr)
-				cancel()
-				doneChan <- true
-				return
-			}
-
-			// Commit transaction with retry
-			var commitErr error
-
-			// Try up to 3 times to commit
-			for attempt := 0; attempt < 3; attempt++ {
-				commitErr = tx.Commit()
-				if commitErr == nil {
-					break
-				}
-
-				// If failed and not on last attempt, wait before retrying
-				if attempt < 2 {
-					time.Sleep(time.Duration(50+rand.Intn(100)) * time.Millisecond)
-				}
-			}
-
-			if commitErr != nil {
-				errChan <- fmt.Errorf("worker %d failed to commit after retries: %w", id, commitErr)
+				return tx.Commit()
+			})
+			if err != nil {
+				errChan <- fmt.Errorf("worker %d failed to insert: %w", id, err)
 				cancel()
 				doneChan <- true
 				return
@@ -612,3 +565,199 @@ func TestContextTimeout(t *testing.T) {
 		t.Errorf("Expected context deadline exceeded error, got: %v", err)
 	}
 }
+
+func TestInsertGetID(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE documents (id INTEGER PRIMARY KEY, title TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	for want := int64(1); want <= 3; want++ {
+		id, err := InsertGetID(ctx, db, "INSERT INTO documents (title) VALUES (?)", fmt.Sprintf("doc %d", want))
+		if err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		if id != want {
+			t.Errorf("Expected id %d, got %d", want, id)
+		}
+	}
+}
+
+func TestVecExtension(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EnableVec = true
+
+	// Open connection to the database
+	db, err := Open(cfg)
+	if errors.Is(err, ErrVecUnavailable) {
+		t.Skip("sqlite-vec not compiled in; run with -tags vec")
+	}
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE VIRTUAL TABLE vec_items USING vec0(embedding float[4])")
+	if err != nil {
+		t.Fatalf("Failed to create vec0 table: %v", err)
+	}
+
+	testVectors := [][]float32{
+		{0.800, 0.579, 0.481, 0.229},
+		{0.406, 0.027, 0.378, 0.056},
+		{0.698, 0.140, 0.073, 0.125},
+		{0.379, 0.637, 0.011, 0.647},
+	}
+	for i, vec := range testVectors {
+		serialized, err := SerializeFloat32(vec)
+		if err != nil {
+			t.Fatalf("Failed to serialize vector: %v", err)
+		}
+		_, err = db.ExecContext(ctx, "INSERT INTO vec_items (rowid, embedding) VALUES (?, ?)", i+1, serialized)
+		if err != nil {
+			t.Fatalf("Failed to insert vector: %v", err)
+		}
+	}
+
+	// KNN query for the vector closest to the first one
+	rows, err := db.QueryContext(ctx, `
+		SELECT rowid, distance FROM vec_items
+		WHERE embedding MATCH vec_f32(?) AND k = 2
+		ORDER BY distance
+	`, "[0.800, 0.579, 0.481, 0.229]")
+	if err != nil {
+		t.Fatalf("Failed to run KNN query: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var distance float64
+		if err := rows.Scan(&id, &distance); err != nil {
+			t.Fatalf("Failed to scan result: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to iterate results: %v", err)
+	}
+
+	if len(ids) != 2 || ids[0] != 1 {
+		t.Errorf("Expected 2 results starting with rowid 1, got %v", ids)
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	version, err := ServerVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to read server version: %v", err)
+	}
+	if version == "" {
+		t.Error("Expected a non-empty version")
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	// Set SQLITE_SPELLFIX to the path of a compiled spellfix extension
+	path := os.Getenv("SQLITE_SPELLFIX")
+	if path == "" {
+		t.Skip("SQLITE_SPELLFIX not set")
+	}
+
+	cfg := DefaultConfig()
+	cfg.Extensions = []string{path}
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var same, different int
+	err = db.QueryRowContext(ctx, "SELECT spellfix1_editdist('parsel', 'parsel'), spellfix1_editdist('parsel', 'parcel')").Scan(&same, &different)
+	if err != nil {
+		t.Fatalf("Failed to call extension function: %v", err)
+	}
+	if same != 0 || different <= 0 {
+		t.Errorf("Expected zero and positive distances, got %d and %d", same, different)
+	}
+
+	// Loading stays disabled for SQL once the connection is set up
+	if _, err := db.ExecContext(ctx, "SELECT load_extension(?)", path); err == nil {
+		t.Error("Expected load_extension to be disabled, got nil")
+	}
+}
+
+func TestExtensionsMissing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Extensions = []string{"/nonexistent/libmissing.so"}
+
+	db, err := Open(cfg)
+	if err == nil {
+		db.Close()
+		t.Fatal("Expected error loading a missing extension, got nil")
+	}
+	if !strings.Contains(err.Error(), "loading extension /nonexistent/libmissing.so") {
+		t.Errorf("Expected error naming the extension, got %v", err)
+	}
+}
+
+func TestBusyTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "busy.db")
+	cfg.Pragmas = DefaultPragmas()
+	cfg.Pragmas["busy_timeout"] = "1234"
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var timeout int
+	if err := db.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatalf("Failed to read busy_timeout: %v", err)
+	}
+	if timeout != 1234 {
+		t.Errorf("Expected busy_timeout 1234, got %d", timeout)
+	}
+
+	// In-memory databases skip it in every DSN form
+	for _, path := range []string{":memory:", "file::memory:", "file:busy?mode=memory"} {
+		if dsn := formatDSN(path, DefaultPragmas()); strings.Contains(dsn, "busy_timeout") {
+			t.Errorf("Expected busy_timeout to be omitted for %s, got %q", path, dsn)
+		}
+	}
+}
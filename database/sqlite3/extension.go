@@ -0,0 +1,84 @@
+package sqlite3
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// extensionConnector opens connections that load extensions on connect
+type extensionConnector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+// newExtensionConnector returns a connector for dsn that loads the
+// extensions at paths into every new connection
+func newExtensionConnector(dsn string, paths []string) *extensionConnector {
+	drv := &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			for _, path := range paths {
+				if err := loadExtension(conn, path); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	return &extensionConnector{dsn: dsn, driver: drv}
+}
+
+// Connect implements driver.Connector
+func (c *extensionConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver implements driver.Connector
+func (c *extensionConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// loadExtension loads the extension at path into conn. LoadExtension
+// enables extension loading only for the duration of the call, so SQL run
+// later on the connection cannot load libraries with load_extension().
+// The entry points are tried the way SQLite does when none is given.
+func loadExtension(conn *sqlite3.SQLiteConn, path string) error {
+	var errs []error
+	for _, entry := range extensionEntryPoints(path) {
+		err := conn.LoadExtension(path, entry)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("loading extension %s: %w", path, errors.Join(errs...))
+}
+
+// extensionEntryPoints returns the init functions SQLite looks for in an
+// extension without an explicit entry point: sqlite3_extension_init, then
+// one named after the file, so libspellfix.so gives sqlite3_spellfix_init
+func extensionEntryPoints(path string) []string {
+	name := filepath.Base(path)
+	name = strings.TrimPrefix(name, "lib")
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+
+	entries := []string{"sqlite3_extension_init"}
+	if name != "" {
+		entries = append(entries, "sqlite3_"+name+"_init")
+	}
+	return entries
+}
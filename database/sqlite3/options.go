@@ -2,6 +2,8 @@ package sqlite3
 
 import (
 	"strings"
+
+	"github.com/parsel-email/lib-go/database/internal/sqlutil"
 )
 
 // Pragmas represents SQLite/libSQL connection pragmas
@@ -16,6 +18,7 @@ func DefaultPragmas() Pragmas {
 		"cache_size":   "-2000",     // Use up to 2MB of memory for caching
 		"temp_store":   "MEMORY",    // Store temporary tables in memory
 		"mmap_size":    "268435456", // Memory-mapped I/O (256MB)
+		"busy_timeout": "5000",      // Wait up to 5 seconds for locks to clear
 	}
 }
 
@@ -29,6 +32,14 @@ func formatDSN(path string, pragmas Pragmas) string {
 
 	// Add pragmas
 	for key, value := range pragmas {
+		if key == "busy_timeout" {
+			// Lock waits are irrelevant for a private in-memory database
+			if sqlutil.IsMemory(path) {
+				continue
+			}
+			// go-sqlite3 only reads the timeout from its own parameter
+			key = "_busy_timeout"
+		}
 		params = append(params, key+"="+value)
 	}
 
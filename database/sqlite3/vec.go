@@ -0,0 +1,14 @@
+//go:build vec
+
+package sqlite3
+
+import (
+	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
+)
+
+// enableVec registers sqlite-vec as an auto extension, so every connection
+// opened afterwards has vec0 tables and the vec_* functions
+func enableVec() error {
+	sqlite_vec.Auto()
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !vec
+
+package sqlite3
+
+// enableVec reports that sqlite-vec was not compiled in
+func enableVec() error {
+	return ErrVecUnavailable
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeLayout is how Time values are stored: RFC 3339 in UTC with a fixed
+// number of fractional digits, so stored times sort correctly as text
+const TimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// timeLayouts are the text forms Time accepts when scanning: its own, those
+// written by the drivers for time.Time arguments and SQLite's datetime()
+// and CURRENT_TIMESTAMP, which are UTC
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// Time stores a timestamp the same way on every backend, such as the
+// received_at of an email. It is written as TimeLayout in UTC and read back
+// as the same instant, in Config.Location when scanned into a struct by Get
+// or QueryIter and in UTC otherwise.
+type Time struct {
+	time.Time
+}
+
+// NewTime wraps t as a Time
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// Value implements driver.Valuer by formatting the time in UTC
+func (t Time) Value() (driver.Value, error) {
+	return t.UTC().Format(TimeLayout), nil
+}
+
+// Scan implements sql.Scanner. It accepts times parsed by the driver, text
+// in RFC 3339 or SQLite's date and time formats, and integer Unix seconds.
+// NULL leaves the zero time.
+func (t *Time) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case time.Time:
+		t.Time = v.UTC()
+	case int64:
+		t.Time = time.Unix(v, 0).UTC()
+	case []byte:
+		return t.Scan(string(v))
+	case string:
+		parsed, err := parseTime(v)
+		if err != nil {
+			return err
+		}
+		t.Time = parsed
+	default:
+		return fmt.Errorf("cannot scan %T into Time", src)
+	}
+	return nil
+}
+
+// parseTime parses s in any of timeLayouts, treating times without a zone
+// as UTC
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if parsed, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", s)
+}
+
+// Location returns the time zone Time values are converted to when scanned
+// into structs, Config.Location or UTC if unset
+func (db *DB) Location() *time.Location {
+	if db.cfg.Location != nil {
+		return db.cfg.Location
+	}
+	return time.UTC
+}
+
+// localizeTimes converts the Time values among scanned targets to loc
+func localizeTimes(targets []any, loc *time.Location) {
+	for _, target := range targets {
+		switch v := target.(type) {
+		case *Time:
+			v.Time = v.In(loc)
+		case *Null[Time]:
+			v.Val.Time = v.Val.In(loc)
+		}
+	}
+}
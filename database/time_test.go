@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tursodatabase/libsql-client-go/libsql"
+)
+
+// timeInstant has a zone and nanoseconds so both must survive storage
+var timeInstant = time.Date(2024, 3, 10, 1, 30, 0, 123456789, time.FixedZone("PST", -8*3600))
+
+// checkTimeBackend stores timeInstant through db and reads it back directly
+func checkTimeBackend(t *testing.T, ctx context.Context, db *sql.DB) {
+	t.Helper()
+
+	_, err := db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, received_at DATETIME, sent_at TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO emails (received_at, sent_at) VALUES (?, ?)", NewTime(timeInstant), NewTime(timeInstant))
+	if err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRowContext(ctx, "SELECT CAST(sent_at AS TEXT) FROM emails").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read stored text: %v", err)
+	}
+	if stored != "2024-03-10T09:30:00.123456789Z" {
+		t.Errorf("Expected UTC RFC 3339 text, got %s", stored)
+	}
+
+	var received, sent Time
+	if err := db.QueryRowContext(ctx, "SELECT received_at, sent_at FROM emails").Scan(&received, &sent); err != nil {
+		t.Fatalf("Failed to scan times: %v", err)
+	}
+	for _, got := range []Time{received, sent} {
+		if !got.Equal(timeInstant) {
+			t.Errorf("Expected %v, got %v", timeInstant, got)
+		}
+		if got.Location() != time.UTC {
+			t.Errorf("Expected UTC, got %v", got.Location())
+		}
+	}
+}
+
+func TestTimeBackends(t *testing.T) {
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("sqlite3", func(t *testing.T) {
+		db, err := sql.Open(DriverSQLite3, filepath.Join(t.TempDir(), "time.db"))
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer db.Close()
+		checkTimeBackend(t, ctx, db)
+	})
+
+	t.Run("libsql", func(t *testing.T) {
+		connector, err := libsql.NewConnector("file:" + filepath.Join(t.TempDir(), "time.db"))
+		if err != nil {
+			t.Fatalf("Failed to create connector: %v", err)
+		}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		checkTimeBackend(t, ctx, db)
+	})
+}
+
+func TestTimeLocation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Location = time.FixedZone("CET", 3600)
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE emails (id INTEGER PRIMARY KEY, received_at DATETIME, read_at TEXT)")
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO emails (received_at) VALUES (?)", NewTime(timeInstant))
+	if err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+
+	var row struct {
+		ReceivedAt Time       `db:"received_at"`
+		ReadAt     Null[Time] `db:"read_at"`
+	}
+	if err := db.Get(ctx, &row, "SELECT received_at, read_at FROM emails"); err != nil {
+		t.Fatalf("Failed to get row: %v", err)
+	}
+	if !row.ReceivedAt.Equal(timeInstant) || row.ReceivedAt.Location() != cfg.Location {
+		t.Errorf("Expected %v in CET, got %v", timeInstant, row.ReceivedAt)
+	}
+	if row.ReadAt.Valid {
+		t.Errorf("Expected NULL read_at, got %v", row.ReadAt.Val)
+	}
+
+	var single Time
+	if err := db.Get(ctx, &single, "SELECT received_at FROM emails"); err != nil {
+		t.Fatalf("Failed to get time: %v", err)
+	}
+	if single.Location() != cfg.Location {
+		t.Errorf("Expected CET, got %v", single.Location())
+	}
+}
+
+func TestTimeScan(t *testing.T) {
+	want := time.Date(2024, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		src  any
+	}{
+		{"layout", "2024-03-10T09:30:00.000000000Z"},
+		{"rfc3339 offset", "2024-03-10T10:30:00+01:00"},
+		{"driver format", "2024-03-10 09:30:00+00:00"},
+		{"current_timestamp", "2024-03-10 09:30:00"},
+		{"bytes", []byte("2024-03-10T09:30:00Z")},
+		{"unix seconds", want.Unix()},
+		{"time", want.In(time.FixedZone("X", 7200))},
+	}
+	for _, tt := range tests {
+		var got Time
+		if err := got.Scan(tt.src); err != nil {
+			t.Errorf("%s: failed to scan: %v", tt.name, err)
+			continue
+		}
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("%s: expected %v, got %v", tt.name, want, got.Time)
+		}
+	}
+
+	var got Time
+	if err := got.Scan("yesterday"); err == nil {
+		t.Error("Expected error scanning an invalid time, got nil")
+	}
+	if err := got.Scan(nil); err != nil || !got.IsZero() {
+		t.Errorf("Expected zero time from NULL, got %v: %v", got, err)
+	}
+}
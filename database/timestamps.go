@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// InstallTimestamps creates triggers maintaining the created_at and
+// updated_at columns of table: both are filled in on insert when left NULL,
+// and updated_at is set to CURRENT_TIMESTAMP on every update that does not
+// set it explicitly. It is safe to call more than once.
+func (db *DB) InstallTimestamps(ctx context.Context, table string) error {
+	quoted, err := quoteIdent(table)
+	if err != nil {
+		return err
+	}
+
+	var ddl string
+	err = db.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(sql), '') FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&ddl)
+	if err != nil {
+		return fmt.Errorf("looking up table %s: %w", table, err)
+	}
+	if ddl == "" {
+		return fmt.Errorf("table %s does not exist", table)
+	}
+
+	// The triggers find the changed row by rowid
+	if strings.Contains(strings.ToUpper(ddl), "WITHOUT ROWID") {
+		return fmt.Errorf("table %s is WITHOUT ROWID and cannot use timestamp triggers", table)
+	}
+
+	var missing []string
+	for _, col := range []string{"created_at", "updated_at"} {
+		var found bool
+		err := db.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM pragma_table_info(?) WHERE name = ?)", table, col).Scan(&found)
+		if err != nil {
+			return fmt.Errorf("checking column %s of %s: %w", col, table, err)
+		}
+		if !found {
+			missing = append(missing, col)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("table %s is missing timestamp columns: %s", table, strings.Join(missing, ", "))
+	}
+
+	insertTrigger, err := quoteIdent(table + "_timestamps_insert")
+	if err != nil {
+		return err
+	}
+	updateTrigger, err := quoteIdent(table + "_timestamps_update")
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s AFTER INSERT ON %s
+			WHEN NEW.created_at IS NULL OR NEW.updated_at IS NULL
+			BEGIN
+				UPDATE %[2]s SET
+					created_at = COALESCE(NEW.created_at, CURRENT_TIMESTAMP),
+					updated_at = COALESCE(NEW.updated_at, CURRENT_TIMESTAMP)
+				WHERE rowid = NEW.rowid;
+			END`, insertTrigger, quoted),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s AFTER UPDATE ON %s
+			WHEN NEW.updated_at IS OLD.updated_at
+			BEGIN
+				UPDATE %[2]s SET updated_at = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid;
+			END`, updateTrigger, quoted),
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("creating timestamp trigger on %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInstallTimestamps(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE threads (
+		id INTEGER PRIMARY KEY,
+		subject TEXT,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	if err := db.InstallTimestamps(ctx, "threads"); err != nil {
+		t.Fatalf("Failed to install timestamps: %v", err)
+	}
+	// Installing again is a no-op
+	if err := db.InstallTimestamps(ctx, "threads"); err != nil {
+		t.Fatalf("Failed to reinstall timestamps: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "INSERT INTO threads (subject) VALUES ('hello')"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	var created, updated time.Time
+	if err := db.QueryRowContext(ctx, "SELECT created_at, updated_at FROM threads").Scan(&created, &updated); err != nil {
+		t.Fatalf("Failed to read timestamps: %v", err)
+	}
+	if created.IsZero() || updated.IsZero() {
+		t.Fatalf("Expected timestamps to be set on insert, got %v and %v", created, updated)
+	}
+
+	// Backdate the row so an update within the same second still advances it
+	past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := db.ExecContext(ctx, "UPDATE threads SET created_at = ?, updated_at = ?", past, past); err != nil {
+		t.Fatalf("Failed to backdate row: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE threads SET subject = 're: hello'"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+
+	if err := db.QueryRowContext(ctx, "SELECT created_at, updated_at FROM threads").Scan(&created, &updated); err != nil {
+		t.Fatalf("Failed to read timestamps: %v", err)
+	}
+	if !created.Equal(past) {
+		t.Errorf("Expected created_at to stay %v, got %v", past, created)
+	}
+	if !updated.After(past) {
+		t.Errorf("Expected updated_at to advance past %v, got %v", past, updated)
+	}
+}
+
+func TestInstallTimestampsMissingColumns(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE plain (id INTEGER PRIMARY KEY, created_at TIMESTAMP)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	tests := []string{"plain", "missing_table", "bad; name"}
+	for _, table := range tests {
+		if err := db.InstallTimestamps(context.Background(), table); err == nil {
+			t.Errorf("Expected error for table %q, got nil", table)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a client span for a statement. Only the parameterized
+// statement is recorded, never the argument values.
+func (db *DB) startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return db.cfg.Tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+// recordError marks a span as failed
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
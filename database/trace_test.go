@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	// Record spans in memory
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer provider.Shutdown(context.Background())
+
+	cfg := DefaultConfig()
+	cfg.Tracer = provider.Tracer("database_test")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE traced (id INTEGER PRIMARY KEY, value TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO traced (value) VALUES (?)", "secret"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT value FROM traced")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	rows.Close()
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM traced").Scan(&count); err != nil {
+		t.Fatalf("Failed to query row: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	tx.Rollback()
+
+	spans := recorder.Ended()
+	expected := []string{"db.Exec", "db.Exec", "db.Query", "db.QueryRow", "db.BeginTx"}
+	if len(spans) != len(expected) {
+		t.Fatalf("Expected %d spans, got %d", len(expected), len(spans))
+	}
+	for i, name := range expected {
+		if spans[i].Name() != name {
+			t.Errorf("Expected span %d to be %s, got %s", i, name, spans[i].Name())
+		}
+	}
+
+	// The insert records its statement and row count but not its arguments
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[1].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["db.statement"].AsString(); got != "INSERT INTO traced (value) VALUES (?)" {
+		t.Errorf("Expected parameterized statement, got %q", got)
+	}
+	if got := attrs["db.rows_affected"].AsInt64(); got != 1 {
+		t.Errorf("Expected 1 row affected, got %d", got)
+	}
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// externalContentPattern matches the content option of an FTS5 table that
+// stores its text elsewhere or not at all
+var externalContentPattern = regexp.MustCompile(`(?i)\bcontent\s*=`)
+
+// TruncateAll deletes every row from every user table except those listed,
+// and resets their AUTOINCREMENT sequences. Foreign keys are disabled while
+// the tables are cleared, so the order of deletion does not matter. Internal
+// sqlite_ tables and the shadow tables of virtual tables are skipped; FTS5
+// indexes are emptied directly. The schema is left untouched.
+func (db *DB) TruncateAll(ctx context.Context, except ...string) error {
+	skip := make(map[string]bool, len(except))
+	for _, name := range except {
+		skip[name] = true
+	}
+
+	// Foreign key enforcement is per connection and cannot change inside a
+	// transaction, so everything runs on one dedicated connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT l.name, l.type, COALESCE(m.sql, '')
+		FROM pragma_table_list AS l
+		LEFT JOIN sqlite_master AS m ON m.name = l.name
+		WHERE l.schema = 'main' AND l.type IN ('table', 'virtual') AND l.name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY l.name`)
+	if err != nil {
+		return fmt.Errorf("listing tables: %w", err)
+	}
+
+	var tables, virtual []string
+	ddl := map[string]string{}
+	for rows.Next() {
+		var name, kind, sql string
+		if err := rows.Scan(&name, &kind, &sql); err != nil {
+			rows.Close()
+			return fmt.Errorf("scanning table: %w", err)
+		}
+		if skip[name] {
+			continue
+		}
+		if kind == "virtual" {
+			virtual = append(virtual, name)
+			ddl[name] = sql
+		} else {
+			tables = append(tables, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("listing tables: %w", err)
+	}
+
+	var foreignKeys int
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return fmt.Errorf("reading foreign_keys: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("disabling foreign keys: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA foreign_keys = %d", foreignKeys))
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning truncate: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Clear regular tables first so content triggers still find their index rows
+	for _, table := range tables {
+		quoted, err := QuoteIdent(table)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+quoted); err != nil {
+			return fmt.Errorf("truncating %s: %w", table, err)
+		}
+	}
+
+	for _, table := range virtual {
+		quoted, err := QuoteIdent(table)
+		if err != nil {
+			return err
+		}
+		stmt := "DELETE FROM " + quoted
+
+		// External content and contentless FTS5 tables reject DELETE
+		lower := strings.ToLower(ddl[table])
+		if strings.Contains(lower, "using fts5") && externalContentPattern.MatchString(lower) {
+			stmt = fmt.Sprintf("INSERT INTO %[1]s(%[1]s) VALUES ('delete-all')", quoted)
+		}
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("truncating %s: %w", table, err)
+		}
+	}
+
+	// Reset AUTOINCREMENT counters of the cleared tables
+	var hasSequence bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name = 'sqlite_sequence')").Scan(&hasSequence)
+	if err != nil {
+		return fmt.Errorf("checking sqlite_sequence: %w", err)
+	}
+	if hasSequence {
+		for _, table := range tables {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM sqlite_sequence WHERE name = ?", table); err != nil {
+				return fmt.Errorf("resetting sequence of %s: %w", table, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing truncate: %w", err)
+	}
+	return nil
+}
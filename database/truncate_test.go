@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTruncateAll(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "truncate.db")
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Related tables, an external content FTS5 index and a kept table
+	setupFTS5(t, db, ctx)
+	statements := []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT)`,
+		`CREATE TABLE emails (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id))`,
+		`CREATE TABLE notes_fts_meta (id INTEGER PRIMARY KEY)`,
+		`CREATE VIRTUAL TABLE notes_fts USING fts5(body)`,
+		`CREATE TABLE schema_migrations (version INTEGER, dirty BOOLEAN)`,
+		`INSERT INTO users (email) VALUES ('a@example.com'), ('b@example.com')`,
+		`INSERT INTO emails (user_id) VALUES (1), (2)`,
+		`INSERT INTO notes_fts_meta DEFAULT VALUES`,
+		`INSERT INTO notes_fts (body) VALUES ('hello')`,
+		`INSERT INTO schema_migrations VALUES (3, 0)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+
+	if err := db.TruncateAll(ctx, "schema_migrations"); err != nil {
+		t.Fatalf("Failed to truncate: %v", err)
+	}
+
+	for _, table := range []string{"users", "emails", "documents", "documents_fts", "notes_fts", "notes_fts_meta"} {
+		count, err := db.Count(ctx, table, "")
+		if err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("Expected %s to be empty, got %d rows", table, count)
+		}
+	}
+
+	// Excluded tables keep their rows
+	if count, err := db.Count(ctx, "schema_migrations", ""); err != nil || count != 1 {
+		t.Errorf("Expected schema_migrations to keep 1 row, got %d (%v)", count, err)
+	}
+
+	// Sequences restart, foreign keys are enforced again and the index still works
+	result, err := db.ExecContext(ctx, "INSERT INTO users (email) VALUES ('c@example.com')")
+	if err != nil {
+		t.Fatalf("Failed to insert after truncate: %v", err)
+	}
+	if id, _ := result.LastInsertId(); id != 1 {
+		t.Errorf("Expected autoincrement to restart at 1, got %d", id)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO emails (user_id) VALUES (42)"); err == nil {
+		t.Error("Expected foreign key violation after truncate, got nil")
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO documents (title, content) VALUES ('SQLite', 'again')"); err != nil {
+		t.Fatalf("Failed to insert document: %v", err)
+	}
+	results, err := db.SearchFTS5(ctx, "documents_fts", "again", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 search result after truncate, got %d", len(results))
+	}
+}
@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TxMode selects how SQLite acquires locks when a transaction begins
+type TxMode int
+
+const (
+	// TxDeferred acquires locks lazily on first read or write
+	TxDeferred TxMode = iota
+	// TxImmediate acquires the write lock as soon as the transaction begins
+	TxImmediate
+	// TxExclusive additionally prevents readers in non-WAL journal modes
+	TxExclusive
+)
+
+// String returns the SQL keyword for the mode
+func (m TxMode) String() string {
+	switch m {
+	case TxImmediate:
+		return "IMMEDIATE"
+	case TxExclusive:
+		return "EXCLUSIVE"
+	default:
+		return "DEFERRED"
+	}
+}
+
+// Transaction wraps a database transaction
+type Transaction struct {
+	tx       *sql.Tx   // Driver-managed transaction
+	conn     *sql.Conn // Dedicated connection when BEGIN was issued directly
+	readOnly bool
+	db       *DB
+	once     sync.Once
+	release  func() // Returns a borrowed connection instead of closing it
+	done     atomic.Bool
+	recorder *txRecorder // Set only when begun WithTxRecording
+}
+
+// BeginTx starts a new transaction using the configured DefaultTxMode.
+// Setting it to TxImmediate avoids SQLITE_BUSY errors from deferred
+// transactions that deadlock when upgrading to a write lock.
+func (db *DB) BeginTx(ctx context.Context) (*Transaction, error) {
+	return db.beginTx(ctx, db.cfg.DefaultTxMode, nil)
+}
+
+// BeginTxOpts starts a new transaction with the given options. Read-only
+// transactions reject writes; SQLite transactions are always serializable.
+func (db *DB) BeginTxOpts(ctx context.Context, opts *sql.TxOptions) (*Transaction, error) {
+	mode := db.cfg.DefaultTxMode
+	if opts != nil && opts.ReadOnly {
+		// Read-only transactions never need the write lock
+		mode = TxDeferred
+	}
+	return db.beginTx(ctx, mode, opts)
+}
+
+// BeginTxMode starts a new transaction with an explicit SQLite begin mode,
+// which sql.TxOptions cannot express
+func (db *DB) BeginTxMode(ctx context.Context, mode TxMode, opts *sql.TxOptions) (*Transaction, error) {
+	return db.beginTx(ctx, mode, opts)
+}
+
+// beginTx starts a transaction, tracing and logging it when configured
+func (db *DB) beginTx(ctx context.Context, mode TxMode, opts *sql.TxOptions) (*Transaction, error) {
+	var span trace.Span
+	if db.cfg.Tracer != nil {
+		ctx, span = db.startSpan(ctx, "db.BeginTx", "BEGIN "+mode.String())
+		defer span.End()
+		span.SetAttributes(attribute.Bool("db.read_only", opts != nil && opts.ReadOnly))
+	}
+
+	start := time.Now()
+	tx, err := db.openTx(ctx, mode, opts)
+	db.observe(ctx, span, opBegin, "BEGIN "+mode.String(), start, err)
+	if err == nil && ctx.Value(txRecordKey{}) != nil {
+		tx.recorder = &txRecorder{}
+	}
+	return tx, err
+}
+
+// openTx starts a transaction, issuing BEGIN on a dedicated connection when
+// the mode or read-only flag cannot be handled by the driver
+func (db *DB) openTx(ctx context.Context, mode TxMode, opts *sql.TxOptions) (*Transaction, error) {
+	if db.closing.Load() {
+		return nil, ErrShutdown
+	}
+
+	readOnly := opts != nil && opts.ReadOnly
+
+	if db.writer != nil && !readOnly {
+		return db.openSerializedTx(ctx, mode)
+	}
+
+	if mode == TxDeferred && !readOnly {
+		tx, err := db.DB.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("beginning transaction: %w", err)
+		}
+		db.activeTx.Add(1)
+		return &Transaction{tx: tx, db: db}, nil
+	}
+
+	// Read-only transactions use the read pool, so they never hold the
+	// single write connection
+	pool := db.DB
+	if readOnly && db.reader != nil {
+		pool = db.reader
+	}
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	if readOnly {
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("beginning read-only transaction: %w", err)
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN "+mode.String()); err != nil {
+		if readOnly {
+			conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+		}
+		conn.Close()
+		return nil, fmt.Errorf("beginning %s transaction: %w", mode, err)
+	}
+
+	db.activeTx.Add(1)
+	return &Transaction{conn: conn, readOnly: readOnly, db: db}, nil
+}
+
+// ExecContext executes a query within the transaction
+func (tx *Transaction) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx.recorder != nil {
+		defer tx.recorder.record(query, time.Now())
+	}
+	var res sql.Result
+	var err error
+	if tx.done.Load() {
+		err = sql.ErrTxDone
+	} else if tx.conn != nil {
+		res, err = tx.conn.ExecContext(ctx, query, args...)
+	} else {
+		res, err = tx.tx.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, statementError(query, err)
+	}
+	return res, nil
+}
+
+// Exec executes a query within the transaction
+func (tx *Transaction) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext runs a query within the transaction
+func (tx *Transaction) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx.recorder != nil {
+		defer tx.recorder.record(query, time.Now())
+	}
+	var rows *sql.Rows
+	var err error
+	if tx.done.Load() {
+		err = sql.ErrTxDone
+	} else if tx.conn != nil {
+		rows, err = tx.conn.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = tx.tx.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, statementError(query, err)
+	}
+	return rows, nil
+}
+
+// Query runs a query within the transaction
+func (tx *Transaction) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext runs a query returning at most one row within the transaction
+func (tx *Transaction) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if tx.recorder != nil {
+		defer tx.recorder.record(query, time.Now())
+	}
+	if tx.done.Load() {
+		return errRow(sql.ErrTxDone)
+	}
+	if tx.conn != nil {
+		return tx.conn.QueryRowContext(ctx, query, args...)
+	}
+	return tx.tx.QueryRowContext(ctx, query, args...)
+}
+
+// QueryRow runs a query returning at most one row within the transaction
+func (tx *Transaction) QueryRow(query string, args ...any) *sql.Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext creates a prepared statement for use within the transaction
+func (tx *Transaction) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if tx.done.Load() {
+		return nil, sql.ErrTxDone
+	}
+	if tx.conn != nil {
+		return tx.conn.PrepareContext(ctx, query)
+	}
+	return tx.tx.PrepareContext(ctx, query)
+}
+
+// Commit commits the transaction
+func (tx *Transaction) Commit() error {
+	if tx.conn == nil {
+		defer tx.finish()
+		return tx.tx.Commit()
+	}
+	// The connection may already belong to another caller
+	if tx.done.Load() {
+		return sql.ErrTxDone
+	}
+
+	_, err := tx.conn.ExecContext(context.Background(), "COMMIT")
+	if err != nil {
+		// Never hand a connection with an open transaction back to the pool
+		tx.conn.ExecContext(context.Background(), "ROLLBACK")
+	}
+	tx.finish()
+	return err
+}
+
+// Rollback aborts the transaction
+func (tx *Transaction) Rollback() error {
+	if tx.conn == nil {
+		defer tx.finish()
+		return tx.tx.Rollback()
+	}
+	if tx.done.Load() {
+		return sql.ErrTxDone
+	}
+
+	_, err := tx.conn.ExecContext(context.Background(), "ROLLBACK")
+	tx.finish()
+	return err
+}
+
+// finish marks the transaction as no longer active and releases any
+// dedicated connection
+func (tx *Transaction) finish() {
+	tx.once.Do(func() {
+		tx.done.Store(true)
+		if tx.release != nil {
+			tx.release()
+		} else if tx.conn != nil {
+			if tx.readOnly {
+				tx.conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+			}
+			tx.conn.Close()
+		}
+		tx.db.activeTx.Add(-1)
+	})
+}
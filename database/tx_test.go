@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openTxTestDB opens a file database that fails fast on lock contention
+func openTxTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "tx.db")
+	cfg.Pragmas["busy_timeout"] = "0"
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	if _, err := db.Exec("CREATE TABLE tx_test (id INTEGER PRIMARY KEY, value TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	return db
+}
+
+func TestReadOnlyTransaction(t *testing.T) {
+	db := openTxTestDB(t)
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTxOpts(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to begin read-only transaction: %v", err)
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM tx_test").Scan(&count); err != nil {
+		t.Fatalf("Failed to read in read-only transaction: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES (?)", "rejected")
+	if err == nil || !strings.Contains(err.Error(), "readonly") {
+		t.Errorf("Expected read-only error, got: %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback: %v", err)
+	}
+
+	// The connection is writable again once returned to the pool
+	for i := 0; i < 3; i++ {
+		if _, err := db.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES (?)", "accepted"); err != nil {
+			t.Fatalf("Failed to write after read-only transaction: %v", err)
+		}
+	}
+}
+
+func TestImmediateTransaction(t *testing.T) {
+	db := openTxTestDB(t)
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A deferred transaction holds no lock until it writes
+	deferred, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin deferred transaction: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES (?)", "outside"); err != nil {
+		t.Errorf("Expected write to succeed alongside deferred transaction, got: %v", err)
+	}
+	if err := deferred.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback: %v", err)
+	}
+
+	// An immediate transaction takes the write lock up front
+	immediate, err := db.BeginTxMode(ctx, TxImmediate, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin immediate transaction: %v", err)
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES (?)", "blocked")
+	if err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("Expected database locked error, got: %v", err)
+	}
+
+	if _, err := immediate.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES (?)", "inside"); err != nil {
+		t.Fatalf("Failed to insert in immediate transaction: %v", err)
+	}
+	if err := immediate.Commit(); err != nil {
+		t.Fatalf("Failed to commit immediate transaction: %v", err)
+	}
+
+	count, err := db.Count(ctx, "tx_test", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got %d", count)
+	}
+}
+
+// countBusyErrors runs concurrent read-then-write transactions and returns
+// how many failed because the database was busy or locked
+func countBusyErrors(t *testing.T, mode TxMode) int {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "busy.db")
+	cfg.MaxOpenConns = 10
+	cfg.DefaultTxMode = mode
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE busy_test (id INTEGER PRIMARY KEY, value TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	const workers = 5
+	const iterations = 5
+	errs := make(chan error, workers*iterations)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				errs <- func() error {
+					tx, err := db.BeginTx(context.Background())
+					if err != nil {
+						return err
+					}
+					defer tx.Rollback()
+
+					// Read first so deferred transactions must upgrade their lock
+					var count int
+					if err := tx.QueryRow("SELECT COUNT(*) FROM busy_test").Scan(&count); err != nil {
+						return err
+					}
+					time.Sleep(2 * time.Millisecond)
+
+					if _, err := tx.Exec("INSERT INTO busy_test (value) VALUES (?)", fmt.Sprintf("%d-%d", id, i)); err != nil {
+						return err
+					}
+					return tx.Commit()
+				}()
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	busy := 0
+	for err := range errs {
+		if err == nil {
+			continue
+		}
+		if !strings.Contains(err.Error(), "locked") && !strings.Contains(err.Error(), "busy") {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		busy++
+	}
+	return busy
+}
+
+func TestDefaultTxModeImmediate(t *testing.T) {
+	deferredBusy := countBusyErrors(t, TxDeferred)
+	immediateBusy := countBusyErrors(t, TxImmediate)
+
+	t.Logf("busy errors: deferred=%d immediate=%d", deferredBusy, immediateBusy)
+
+	if immediateBusy != 0 {
+		t.Errorf("Expected no busy errors with IMMEDIATE transactions, got %d", immediateBusy)
+	}
+	if deferredBusy < immediateBusy {
+		t.Errorf("Expected DEFERRED to see at least as many busy errors as IMMEDIATE")
+	}
+}
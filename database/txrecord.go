@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// txRecordKey is the context key set by WithTxRecording
+type txRecordKey struct{}
+
+// WithTxRecording returns a context that makes transactions begun with it
+// record each statement and its duration, for profiling multi-step work
+// such as ingest-and-index. Transactions begun without it record nothing.
+func WithTxRecording(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txRecordKey{}, true)
+}
+
+// TxStatement is a statement run in a recorded transaction
+type TxStatement struct {
+	Query    string
+	Duration time.Duration // Until the call returned; rows are read after
+}
+
+// txRecorder collects the statements of a transaction
+type txRecorder struct {
+	mu         sync.Mutex
+	statements []TxStatement
+}
+
+// record appends query with the time elapsed since start
+func (r *txRecorder) record(query string, start time.Time) {
+	elapsed := time.Since(start)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, TxStatement{Query: query, Duration: elapsed})
+}
+
+// Statements returns the statements run in the transaction in order, or
+// nil if it was not begun with a context from WithTxRecording. It can be
+// called before or after Commit and Rollback.
+func (tx *Transaction) Statements() []TxStatement {
+	if tx.recorder == nil {
+		return nil
+	}
+	tx.recorder.mu.Lock()
+	defer tx.recorder.mu.Unlock()
+	return append([]TxStatement(nil), tx.recorder.statements...)
+}
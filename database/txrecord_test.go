@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTxRecording(t *testing.T) {
+	db := openTxTestDB(t)
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(WithTxRecording(ctx))
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+
+	queries := []string{
+		"INSERT INTO tx_test (value) VALUES ('a')",
+		"UPDATE tx_test SET value = 'b'",
+		"DELETE FROM tx_test WHERE value = 'c'",
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			t.Fatalf("Failed to execute %q: %v", query, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	statements := tx.Statements()
+	if len(statements) != len(queries) {
+		t.Fatalf("Expected %d statements, got %d", len(queries), len(statements))
+	}
+	for i, stmt := range statements {
+		if stmt.Query != queries[i] {
+			t.Errorf("Expected statement %d to be %q, got %q", i, queries[i], stmt.Query)
+		}
+		if stmt.Duration <= 0 {
+			t.Errorf("Expected statement %d to be timed, got %v", i, stmt.Duration)
+		}
+	}
+
+	// Transactions are not recorded by default
+	plain, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer plain.Rollback()
+	if _, err := plain.ExecContext(ctx, queries[0]); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if statements := plain.Statements(); statements != nil {
+		t.Errorf("Expected no statements without recording, got %v", statements)
+	}
+}
@@ -0,0 +1,307 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/parsel-email/lib-go/database/sqlite3"
+)
+
+// ErrVectorDimMismatch is returned when a vector does not have the expected
+// number of dimensions
+var ErrVectorDimMismatch = errors.New("vector dimension mismatch")
+
+// vecColumnTypes lists the metadata column types accepted by vec0
+var vecColumnTypes = map[string]bool{
+	"integer": true,
+	"float":   true,
+	"text":    true,
+	"boolean": true,
+}
+
+// VecColumn is a metadata column stored alongside vectors in a vec0 table
+type VecColumn struct {
+	Name string
+	Type string // integer, float, text, or boolean
+}
+
+// VecOptions configures the creation of a vec0 virtual table
+type VecOptions struct {
+	Column         string // Vector column name; defaults to embedding
+	DistanceMetric string // l2, l1, or cosine; defaults to l2
+	Metadata       []VecColumn
+}
+
+// CreateVecTable creates a sqlite-vec vec0 virtual table holding float32
+// vectors of dims dimensions. It requires the sqlite-vec extension.
+func (db *DB) CreateVecTable(ctx context.Context, name string, dims int, opts VecOptions) error {
+	quoted, err := quoteIdent(name)
+	if err != nil {
+		return err
+	}
+	if dims <= 0 {
+		return fmt.Errorf("dimensions must be positive, got %d", dims)
+	}
+
+	// vec0 parses its column definitions itself and does not accept quoted
+	// names, so they are only validated. Reserved words are fine there.
+	column := opts.Column
+	if column == "" {
+		column = "embedding"
+	}
+	if err := validateIdent(column); err != nil {
+		return err
+	}
+
+	vector := fmt.Sprintf("%s float[%d]", column, dims)
+	switch opts.DistanceMetric {
+	case "":
+	case "l2", "l1", "cosine":
+		vector += " distance_metric=" + opts.DistanceMetric
+	default:
+		return fmt.Errorf("unknown distance metric %q", opts.DistanceMetric)
+	}
+
+	args := []string{vector}
+	for _, col := range opts.Metadata {
+		if err := validateIdent(col.Name); err != nil {
+			return err
+		}
+		typ := strings.ToLower(col.Type)
+		if !vecColumnTypes[typ] {
+			return fmt.Errorf("unsupported vec0 column type %q for %s", col.Type, col.Name)
+		}
+		args = append(args, col.Name+" "+typ)
+	}
+
+	stmt := fmt.Sprintf("CREATE VIRTUAL TABLE %s USING vec0(%s)", quoted, strings.Join(args, ", "))
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("creating vec0 table %s: %w", name, err)
+	}
+	return nil
+}
+
+// InsertVector stores vec in column of the row with rowID, after checking it
+// has wantDim dimensions. Mixing embeddings from different models would
+// otherwise corrupt similarity search without any error.
+func (db *DB) InsertVector(ctx context.Context, table, column string, rowID int64, vec []float32, wantDim int) error {
+	if len(vec) != wantDim {
+		return fmt.Errorf("%w: got %d, want %d", ErrVectorDimMismatch, len(vec), wantDim)
+	}
+	qt, err := quoteIdent(table)
+	if err != nil {
+		return err
+	}
+	qc, err := quoteIdent(column)
+	if err != nil {
+		return err
+	}
+
+	blob, err := sqlite3.SerializeFloat32(vec)
+	if err != nil {
+		return fmt.Errorf("serializing vector: %w", err)
+	}
+
+	stmt := fmt.Sprintf("INSERT INTO %s (rowid, %s) VALUES (?, ?)", qt, qc)
+	if _, err := db.ExecContext(ctx, stmt, rowID, blob); err != nil {
+		return fmt.Errorf("inserting vector into %s: %w", table, err)
+	}
+	return nil
+}
+
+// VectorItem is a vector keyed by the rowid it is stored under
+type VectorItem struct {
+	Key    int64
+	Vector []float32
+}
+
+// UpsertVectors writes items to the embedding column of table in a single
+// transaction, inserting new rows and replacing the vector of existing ones.
+// Statements are batched to stay under the parameter limit. All items must
+// have the same dimension, otherwise nothing is written. The table must have
+// an INTEGER PRIMARY KEY so rowid conflicts can be detected.
+func (db *DB) UpsertVectors(ctx context.Context, table string, items []VectorItem) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	qt, err := quoteIdent(table)
+	if err != nil {
+		return 0, err
+	}
+
+	dim := len(items[0].Vector)
+	if dim == 0 {
+		return 0, fmt.Errorf("vector for key %d is empty", items[0].Key)
+	}
+
+	// Serialize everything up front so a bad item fails before any writes
+	blobs := make([][]byte, len(items))
+	for i, item := range items {
+		if len(item.Vector) != dim {
+			return 0, fmt.Errorf("key %d: %w: got %d, want %d", item.Key, ErrVectorDimMismatch, len(item.Vector), dim)
+		}
+		if blobs[i], err = sqlite3.SerializeFloat32(item.Vector); err != nil {
+			return 0, fmt.Errorf("serializing vector for key %d: %w", item.Key, err)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	const batch = maxParams / 2
+	var count int64
+	for start := 0; start < len(items); start += batch {
+		end := min(start+batch, len(items))
+
+		args := make([]any, 0, 2*(end-start))
+		for i := start; i < end; i++ {
+			args = append(args, items[i].Key, blobs[i])
+		}
+
+		values := strings.TrimSuffix(strings.Repeat("(?, ?), ", end-start), ", ")
+		stmt := fmt.Sprintf(
+			"INSERT INTO %s (rowid, embedding) VALUES %s ON CONFLICT (rowid) DO UPDATE SET embedding = excluded.embedding",
+			qt, values)
+
+		res, err := tx.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return 0, fmt.Errorf("upserting vectors into %s: %w", table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("upserting vectors into %s: %w", table, err)
+		}
+		count += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing vectors: %w", err)
+	}
+	return count, nil
+}
+
+// Neighbor is a row returned by a similarity search
+type Neighbor struct {
+	RowID    int64
+	Distance float64 // Cosine distance: 0 is identical, 2 is opposite
+}
+
+// NearestNeighbors returns the k rows of table whose vectorColumn is closest
+// to query by cosine distance, nearest first. Vectors are stored as
+// little-endian float32 BLOBs and compared in Go, so it works without a
+// vector extension but scans every row. Rows with a NULL vector are skipped
+// and a vector of a different dimension is an error.
+func (db *DB) NearestNeighbors(ctx context.Context, table, vectorColumn string, query []float32, k int) ([]Neighbor, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	if len(query) == 0 {
+		return nil, fmt.Errorf("query vector is empty")
+	}
+	qt, err := quoteIdent(table)
+	if err != nil {
+		return nil, err
+	}
+	qc, err := quoteIdent(vectorColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	queryNorm := norm(query)
+	if queryNorm == 0 {
+		return nil, fmt.Errorf("query vector has zero magnitude")
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT rowid, %s FROM %s WHERE %s IS NOT NULL", qc, qt, qc))
+	if err != nil {
+		return nil, fmt.Errorf("reading vectors from %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var neighbors []Neighbor
+	for rows.Next() {
+		var rowID int64
+		var blob []byte
+		if err := rows.Scan(&rowID, &blob); err != nil {
+			return nil, fmt.Errorf("scanning vector: %w", err)
+		}
+
+		vec, err := sqlite3.DeserializeFloat32(blob)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", rowID, err)
+		}
+		if len(vec) != len(query) {
+			return nil, fmt.Errorf("row %d: vector has %d dimensions, query has %d", rowID, len(vec), len(query))
+		}
+
+		neighbors = append(neighbors, Neighbor{
+			RowID:    rowID,
+			Distance: cosineDistance(query, queryNorm, vec),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating vectors: %w", err)
+	}
+
+	slices.SortFunc(neighbors, func(a, b Neighbor) int {
+		if c := cmp.Compare(a.Distance, b.Distance); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.RowID, b.RowID)
+	})
+	if len(neighbors) > k {
+		neighbors = neighbors[:k]
+	}
+	return neighbors, nil
+}
+
+// Normalize returns a copy of vec scaled to unit L2 length, so cosine
+// similarity between normalized vectors is their dot product. A zero vector
+// is returned unchanged.
+func Normalize(vec []float32) []float32 {
+	out := slices.Clone(vec)
+	NormalizeInPlace(out)
+	return out
+}
+
+// NormalizeInPlace scales vec to unit L2 length, leaving a zero vector as is
+func NormalizeInPlace(vec []float32) {
+	n := norm(vec)
+	if n == 0 {
+		return
+	}
+	for i, v := range vec {
+		vec[i] = float32(float64(v) / n)
+	}
+}
+
+// norm returns the L2 magnitude of vec
+func norm(vec []float32) float64 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
+
+// cosineDistance returns 1 minus the cosine similarity of a and b, given the
+// precomputed magnitude of a. A zero vector b is treated as unrelated.
+func cosineDistance(a []float32, aNorm float64, b []float32) float64 {
+	bNorm := norm(b)
+	if bNorm == 0 {
+		return 1
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return 1 - dot/(aNorm*bNorm)
+}
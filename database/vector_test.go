@@ -0,0 +1,370 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/parsel-email/lib-go/database/sqlite3"
+)
+
+// serializeVector encodes vec as a little-endian float32 BLOB
+func serializeVector(t *testing.T, vec []float32) []byte {
+	t.Helper()
+
+	blob, err := sqlite3.SerializeFloat32(vec)
+	if err != nil {
+		t.Fatalf("Failed to serialize vector: %v", err)
+	}
+	return blob
+}
+
+func TestNearestNeighbors(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE embeddings (id INTEGER PRIMARY KEY, embedding BLOB)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	vectors := map[int64][]float32{
+		1: {1, 0, 0},     // Same direction as the query
+		2: {0, 1, 0},     // Orthogonal
+		3: {-1, 0, 0},    // Opposite
+		4: {1, 1, 0},     // 45 degrees
+		5: {10, 1, 0},    // Nearly parallel, larger magnitude
+		6: {0.9, 0, 0.1}, // Close
+	}
+	for id, vec := range vectors {
+		if _, err := db.ExecContext(ctx, "INSERT INTO embeddings (id, embedding) VALUES (?, ?)", id, serializeVector(t, vec)); err != nil {
+			t.Fatalf("Failed to insert vector: %v", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO embeddings (id, embedding) VALUES (7, NULL)"); err != nil {
+		t.Fatalf("Failed to insert NULL vector: %v", err)
+	}
+
+	neighbors, err := db.NearestNeighbors(ctx, "embeddings", "embedding", []float32{1, 0, 0}, 4)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+
+	want := []int64{1, 5, 6, 4}
+	if len(neighbors) != len(want) {
+		t.Fatalf("Expected %d neighbors, got %d", len(want), len(neighbors))
+	}
+	for i, n := range neighbors {
+		if n.RowID != want[i] {
+			t.Errorf("Expected rank %d to be row %d, got %d", i, want[i], n.RowID)
+		}
+	}
+	if neighbors[0].Distance > 1e-9 {
+		t.Errorf("Expected zero distance for identical vector, got %f", neighbors[0].Distance)
+	}
+
+	// Requesting more rows than exist returns all non-NULL vectors
+	neighbors, err = db.NearestNeighbors(ctx, "embeddings", "embedding", []float32{1, 0, 0}, 100)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(neighbors) != len(vectors) {
+		t.Errorf("Expected %d neighbors, got %d", len(vectors), len(neighbors))
+	}
+	if last := neighbors[len(neighbors)-1]; last.RowID != 3 {
+		t.Errorf("Expected opposite vector last, got row %d", last.RowID)
+	}
+
+	// Dimension mismatches are reported rather than silently ranked
+	if _, err := db.NearestNeighbors(ctx, "embeddings", "embedding", []float32{1, 0}, 3); err == nil {
+		t.Error("Expected error for mismatched dimensions, got nil")
+	}
+}
+
+func TestCreateVecTable(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Invalid arguments are rejected before any SQL runs
+	invalid := []struct {
+		name string
+		dims int
+		opts VecOptions
+	}{
+		{"bad name", 4, VecOptions{}},
+		{"vectors", 0, VecOptions{}},
+		{"vectors", 4, VecOptions{DistanceMetric: "hamming"}},
+		{"vectors", 4, VecOptions{Metadata: []VecColumn{{Name: "folder", Type: "blob"}}}},
+	}
+	for _, c := range invalid {
+		if err := db.CreateVecTable(ctx, c.name, c.dims, c.opts); err == nil {
+			t.Errorf("Expected error for %q with %d dimensions and %+v, got nil", c.name, c.dims, c.opts)
+		}
+	}
+
+	if ok, err := db.HasModule(ctx, "vec0"); err != nil {
+		t.Fatalf("Failed to check for vec0: %v", err)
+	} else if !ok {
+		t.Skip("sqlite-vec extension not loaded")
+	}
+
+	err = db.CreateVecTable(ctx, "email_vectors", 3, VecOptions{
+		Metadata: []VecColumn{{Name: "folder", Type: "text"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create vec0 table: %v", err)
+	}
+
+	vectors := [][]float32{{1, 0, 0}, {0, 1, 0}, {0.9, 0.1, 0}}
+	for i, vec := range vectors {
+		_, err := db.ExecContext(ctx, "INSERT INTO email_vectors (rowid, embedding, folder) VALUES (?, ?, ?)",
+			i+1, serializeVector(t, vec), "inbox")
+		if err != nil {
+			t.Fatalf("Failed to insert vector: %v", err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT rowid FROM email_vectors
+		WHERE embedding MATCH ? AND k = 2 AND folder = 'inbox'
+		ORDER BY distance
+	`, serializeVector(t, []float32{1, 0, 0}))
+	if err != nil {
+		t.Fatalf("Failed to run KNN query: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("Failed to scan result: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("Expected rowids [1 3], got %v", ids)
+	}
+}
+
+func TestInsertVector(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE embeddings (id INTEGER PRIMARY KEY, embedding BLOB)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	vec := []float32{0.25, -1.5, 3}
+	if err := db.InsertVector(ctx, "embeddings", "embedding", 7, vec, 3); err != nil {
+		t.Fatalf("Failed to insert vector: %v", err)
+	}
+
+	var blob []byte
+	if err := db.QueryRowContext(ctx, "SELECT embedding FROM embeddings WHERE id = 7").Scan(&blob); err != nil {
+		t.Fatalf("Failed to read vector: %v", err)
+	}
+	got, err := sqlite3.DeserializeFloat32(blob)
+	if err != nil {
+		t.Fatalf("Failed to deserialize vector: %v", err)
+	}
+	if len(got) != len(vec) {
+		t.Fatalf("Expected %d dimensions, got %d", len(vec), len(got))
+	}
+	for i := range vec {
+		if got[i] != vec[i] {
+			t.Errorf("Expected %v at index %d, got %v", vec[i], i, got[i])
+		}
+	}
+
+	// A vector of the wrong dimension is rejected and nothing is written
+	err = db.InsertVector(ctx, "embeddings", "embedding", 8, []float32{1, 2}, 3)
+	if !errors.Is(err, ErrVectorDimMismatch) {
+		t.Errorf("Expected ErrVectorDimMismatch, got %v", err)
+	}
+	if count, _ := db.Count(ctx, "embeddings", ""); count != 1 {
+		t.Errorf("Expected 1 row after rejected insert, got %d", count)
+	}
+}
+
+func TestUpsertVectors(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE embeddings (id INTEGER PRIMARY KEY, embedding BLOB, model TEXT)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO embeddings (id, embedding, model) VALUES (1, NULL, 'minilm')"); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+
+	// More items than fit in one statement, including an existing key
+	items := make([]VectorItem, 1200)
+	for i := range items {
+		items[i] = VectorItem{Key: int64(i + 1), Vector: []float32{float32(i), 1}}
+	}
+	n, err := db.UpsertVectors(ctx, "embeddings", items)
+	if err != nil {
+		t.Fatalf("Failed to upsert vectors: %v", err)
+	}
+	if n != int64(len(items)) {
+		t.Errorf("Expected %d rows affected, got %d", len(items), n)
+	}
+	if count, _ := db.Count(ctx, "embeddings", ""); count != int64(len(items)) {
+		t.Errorf("Expected %d rows, got %d", len(items), count)
+	}
+
+	// Upserting keeps the other columns of existing rows
+	var model string
+	var blob []byte
+	if err := db.QueryRowContext(ctx, "SELECT model, embedding FROM embeddings WHERE id = 1").Scan(&model, &blob); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if model != "minilm" || len(blob) != 8 {
+		t.Errorf("Expected model to be kept and vector set, got %q and %d bytes", model, len(blob))
+	}
+
+	// One mismatched item fails the whole batch
+	_, err = db.UpsertVectors(ctx, "embeddings", []VectorItem{
+		{Key: 5000, Vector: []float32{1, 2}},
+		{Key: 5001, Vector: []float32{1, 2, 3}},
+	})
+	if !errors.Is(err, ErrVectorDimMismatch) {
+		t.Errorf("Expected ErrVectorDimMismatch, got %v", err)
+	}
+	if exists, _ := db.Exists(ctx, "embeddings", "id = ?", 5000); exists {
+		t.Error("Expected no rows written from a failed batch")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	vectors := [][]float32{
+		{3, 4},
+		{0.001, -0.002, 0.003},
+		{1e6, 1e6, 1e6, 1e6},
+	}
+	for _, vec := range vectors {
+		original := slices.Clone(vec)
+		got := Normalize(vec)
+		if magnitude := norm(got); math.Abs(magnitude-1) > 1e-6 {
+			t.Errorf("Expected unit magnitude for %v, got %f", vec, magnitude)
+		}
+		if !slices.Equal(vec, original) {
+			t.Errorf("Expected Normalize not to modify its input, got %v", vec)
+		}
+
+		NormalizeInPlace(vec)
+		if !slices.Equal(vec, got) {
+			t.Errorf("Expected NormalizeInPlace to match Normalize, got %v and %v", vec, got)
+		}
+	}
+
+	// Direction is preserved
+	if got := Normalize([]float32{3, 4}); math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("Expected [0.6 0.8], got %v", got)
+	}
+
+	// A zero vector has no direction and is left unchanged
+	zero := []float32{0, 0, 0}
+	NormalizeInPlace(zero)
+	for _, v := range append(Normalize(zero), zero...) {
+		if v != 0 || math.IsNaN(float64(v)) {
+			t.Errorf("Expected zero vector to stay zero, got %v", zero)
+		}
+	}
+	if got := Normalize(nil); len(got) != 0 {
+		t.Errorf("Expected empty result for nil, got %v", got)
+	}
+}
+
+// benchmarkVectors opens a file database with an embeddings table and
+// returns n 384-dimension vectors to write into it
+func benchmarkVectors(b *testing.B, n int) (*DB, []VectorItem) {
+	b.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Path = filepath.Join(b.TempDir(), "bench.db")
+	db, err := Open(cfg)
+	if err != nil {
+		b.Fatalf("Failed to open database: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec("CREATE TABLE embeddings (id INTEGER PRIMARY KEY, embedding BLOB)"); err != nil {
+		b.Fatalf("Failed to create table: %v", err)
+	}
+
+	items := make([]VectorItem, n)
+	for i := range items {
+		vec := make([]float32, 384)
+		for j := range vec {
+			vec[j] = float32(i*j%97) / 97
+		}
+		items[i] = VectorItem{Key: int64(i + 1), Vector: vec}
+	}
+	return db, items
+}
+
+func BenchmarkUpsertVectorsBatched(b *testing.B) {
+	db, items := benchmarkVectors(b, 1000)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.UpsertVectors(ctx, "embeddings", items); err != nil {
+			b.Fatalf("Failed to upsert vectors: %v", err)
+		}
+	}
+}
+
+func BenchmarkUpsertVectorsPerRow(b *testing.B) {
+	db, items := benchmarkVectors(b, 1000)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			blob, err := sqlite3.SerializeFloat32(item.Vector)
+			if err != nil {
+				b.Fatalf("Failed to serialize vector: %v", err)
+			}
+			_, err = db.ExecContext(ctx,
+				"INSERT INTO embeddings (id, embedding) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET embedding = excluded.embedding",
+				item.Key, blob)
+			if err != nil {
+				b.Fatalf("Failed to upsert vector: %v", err)
+			}
+		}
+	}
+}
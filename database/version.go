@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Driver names reported by DriverName. They match the DriverName constants
+// of the sqlite3 and libsql packages.
+const (
+	DriverSQLite3 = "sqlite3"
+	DriverLibSQL  = "libsql"
+)
+
+// DriverName returns the backend the database is using: DriverLibSQL for
+// remote libSQL databases and DriverSQLite3 for local files and memory
+func (db *DB) DriverName(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if isRemote(db.cfg.Path) {
+		return DriverLibSQL, nil
+	}
+	return DriverSQLite3, nil
+}
+
+// ServerVersion returns the SQLite version of the connected database, which
+// can be used to gate features such as RETURNING (3.35). libSQL does not
+// expose its own version over SQL, so for remote databases this is the
+// SQLite version it is based on.
+func (db *DB) ServerVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("reading server version: %w", err)
+	}
+	return version, nil
+}
+
+// HasFunction reports whether the SQL function name is available, so callers
+// can branch on optional features instead of parsing "no such function"
+// errors
+func (db *DB) HasFunction(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pragma_function_list WHERE name = ?)", strings.ToLower(name)).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("checking for function %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+// HasModule reports whether the virtual table module name, such as fts5 or
+// vec0, is available
+func (db *DB) HasModule(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pragma_module_list WHERE name = ?)", strings.ToLower(name)).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("checking for module %s: %w", name, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerVersion(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	version, err := db.ServerVersion(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read server version: %v", err)
+	}
+	if version == "" || !strings.HasPrefix(version, "3.") {
+		t.Errorf("Expected a SQLite 3 version, got %q", version)
+	}
+
+	name, err := db.DriverName(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read driver name: %v", err)
+	}
+	if name != DriverSQLite3 {
+		t.Errorf("Expected driver %q, got %q", DriverSQLite3, name)
+	}
+}
+
+func TestHasFunctionAndModule(t *testing.T) {
+	db, err := Open(DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	functions := map[string]bool{
+		"json_extract":     true,
+		"JSON_EXTRACT":     true,
+		"no_such_function": false,
+	}
+	for name, want := range functions {
+		got, err := db.HasFunction(ctx, name)
+		if err != nil {
+			t.Fatalf("Failed to check function %s: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("Expected HasFunction(%q) to be %v, got %v", name, want, got)
+		}
+	}
+
+	modules := map[string]bool{
+		"fts5":           true,
+		"no_such_module": false,
+	}
+	for name, want := range modules {
+		got, err := db.HasModule(ctx, name)
+		if err != nil {
+			t.Fatalf("Failed to check module %s: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("Expected HasModule(%q) to be %v, got %v", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// txContextKey is the context key for the transaction of WithTransaction
+type txContextKey struct{}
+
+// txContext is the transaction in progress in a context and how many
+// savepoints deep the current call is
+type txContext struct {
+	tx    *Transaction
+	depth int
+}
+
+// WithTransaction runs fn in a transaction, committing it if fn returns nil
+// and rolling it back if fn returns an error or panics. The context passed
+// to fn carries the transaction, so a WithTransaction call nested inside fn
+// for the same database uses a savepoint on it instead of beginning a new
+// transaction, which SQLite's single writer would block forever. An error
+// from a nested fn rolls back only to its savepoint, leaving the outer
+// transaction to carry on or fail as it chooses.
+func (db *DB) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *Transaction) error) (err error) {
+	if outer, ok := ctx.Value(txContextKey{}).(*txContext); ok && outer.tx.db == db {
+		return outer.nest(ctx, fn)
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				err = errors.Join(err, fmt.Errorf("rolling back transaction: %w", rbErr))
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("committing transaction: %w", err)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txContextKey{}, &txContext{tx: tx}), tx)
+}
+
+// nest runs fn within a savepoint on the transaction in progress
+func (c *txContext) nest(ctx context.Context, fn func(ctx context.Context, tx *Transaction) error) (err error) {
+	inner := &txContext{tx: c.tx, depth: c.depth + 1}
+	name := fmt.Sprintf("tx_%d", inner.depth)
+	if err := c.tx.Savepoint(ctx, name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			c.tx.RollbackTo(ctx, name)
+			c.tx.ReleaseSavepoint(ctx, name)
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := c.tx.RollbackTo(ctx, name); rbErr != nil {
+				err = errors.Join(err, rbErr)
+				return
+			}
+		}
+		if relErr := c.tx.ReleaseSavepoint(ctx, name); relErr != nil {
+			err = errors.Join(err, relErr)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txContextKey{}, inner), c.tx)
+}
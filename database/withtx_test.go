@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTransactionNested(t *testing.T) {
+	db := openTxTestDB(t)
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errInner := errors.New("inner failed")
+	err := db.WithTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES ('outer')"); err != nil {
+			return err
+		}
+
+		// A failing nested call only undoes its own writes
+		err := db.WithTransaction(ctx, func(ctx context.Context, inner *Transaction) error {
+			if inner != tx {
+				t.Error("Expected the nested call to share the outer transaction")
+			}
+			if _, err := inner.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES ('discarded')"); err != nil {
+				return err
+			}
+			return errInner
+		})
+		if !errors.Is(err, errInner) {
+			t.Errorf("Expected inner error, got %v", err)
+		}
+
+		// A succeeding nested call keeps its writes
+		return db.WithTransaction(ctx, func(ctx context.Context, inner *Transaction) error {
+			_, err := inner.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES ('kept')")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatalf("Failed to run transaction: %v", err)
+	}
+
+	var values []string
+	rows, err := db.QueryContext(ctx, "SELECT value FROM tx_test ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to query rows: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatalf("Failed to scan row: %v", err)
+		}
+		values = append(values, value)
+	}
+	if len(values) != 2 || values[0] != "outer" || values[1] != "kept" {
+		t.Errorf("Expected [outer kept], got %v", values)
+	}
+}
+
+func TestWithTransactionRollback(t *testing.T) {
+	db := openTxTestDB(t)
+	defer db.Close()
+
+	// Create a context with timeout
+	ctx, cancel := WithContext(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errOuter := errors.New("outer failed")
+	err := db.WithTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		err := db.WithTransaction(ctx, func(ctx context.Context, inner *Transaction) error {
+			_, err := inner.ExecContext(ctx, "INSERT INTO tx_test (value) VALUES ('inner')")
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return errOuter
+	})
+	if !errors.Is(err, errOuter) {
+		t.Fatalf("Expected outer error, got %v", err)
+	}
+
+	// Released savepoints are undone with the outer transaction
+	count, err := db.Count(ctx, "tx_test", "")
+	if err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no rows after rollback, got %d", count)
+	}
+}
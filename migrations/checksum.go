@@ -0,0 +1,208 @@
+// Package migrations applies and verifies schema migrations
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChecksumTable stores the hash of each applied up migration
+const ChecksumTable = "schema_migrations_checksums"
+
+// ErrChecksumMismatch is returned when an applied migration file has changed
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// filenamePattern matches migration filenames such as 1746507520_user.up.sql
+var filenamePattern = regexp.MustCompile(`^([0-9]+)_(.*)\.(up|down)\.sql$`)
+
+// migrationFile is a parsed migration filename
+type migrationFile struct {
+	Version   uint64
+	Name      string
+	Direction string // up or down
+	Path      string
+}
+
+// parseFilename parses a migration filename, reporting whether it matched
+func parseFilename(name string) (migrationFile, bool) {
+	m := filenamePattern.FindStringSubmatch(name)
+	if m == nil {
+		return migrationFile{}, false
+	}
+	version, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return migrationFile{}, false
+	}
+	return migrationFile{Version: version, Name: m[2], Direction: m[3], Path: name}, true
+}
+
+// upFiles returns the up migrations in source ordered by version
+func upFiles(source fs.FS) ([]migrationFile, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	var files []migrationFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if f, ok := parseFilename(entry.Name()); ok && f.Direction == "up" {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+	return files, nil
+}
+
+// checksum returns the hex-encoded SHA-256 of a migration file
+func checksum(source fs.FS, path string) (string, error) {
+	data, err := fs.ReadFile(source, path)
+	if err != nil {
+		return "", fmt.Errorf("reading migration %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ensureChecksumTable creates the checksum table if it does not exist
+func ensureChecksumTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+ChecksumTable+` (
+		version INTEGER PRIMARY KEY,
+		filename TEXT NOT NULL,
+		checksum TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating checksum table: %w", err)
+	}
+	return nil
+}
+
+// appliedVersion returns the current clean migration version, or false if
+// no migration has been applied
+func appliedVersion(ctx context.Context, db *sql.DB) (uint64, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) || (err != nil && strings.Contains(err.Error(), "no such table")) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("reading migration version: %w", err)
+	}
+	if version < 0 {
+		return 0, false, nil
+	}
+
+	// A dirty version was only partly applied, so it has no trusted checksum
+	if dirty {
+		if version == 0 {
+			return 0, false, nil
+		}
+		version--
+	}
+	return uint64(version), true, nil
+}
+
+// RecordChecksums stores the checksum of every applied up migration that has
+// not been recorded yet. Existing checksums are never overwritten, while
+// checksums of migrations that were rolled back are removed.
+func RecordChecksums(ctx context.Context, db *sql.DB, source fs.FS) error {
+	if err := ensureChecksumTable(ctx, db); err != nil {
+		return err
+	}
+
+	current, ok, err := appliedVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// Forget rolled back migrations so they may be edited and reapplied
+	stale := int64(current)
+	if !ok {
+		stale = -1
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM "+ChecksumTable+" WHERE version > ?", stale); err != nil {
+		return fmt.Errorf("removing stale checksums: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	files, err := upFiles(source)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if f.Version > current {
+			break
+		}
+		sum, err := checksum(source, f.Path)
+		if err != nil {
+			return err
+		}
+		_, err = db.ExecContext(ctx,
+			"INSERT OR IGNORE INTO "+ChecksumTable+" (version, filename, checksum) VALUES (?, ?, ?)",
+			f.Version, f.Path, sum)
+		if err != nil {
+			return fmt.Errorf("recording checksum for %s: %w", f.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// Verify compares each applied up migration against its recorded checksum
+// and returns an error wrapping ErrChecksumMismatch listing the changed or
+// missing files
+func Verify(ctx context.Context, db *sql.DB, source fs.FS) error {
+	if err := ensureChecksumTable(ctx, db); err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version, filename, checksum FROM "+ChecksumTable+" ORDER BY version")
+	if err != nil {
+		return fmt.Errorf("reading checksums: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var version uint64
+		var filename, recorded string
+		if err := rows.Scan(&version, &filename, &recorded); err != nil {
+			return fmt.Errorf("scanning checksum: %w", err)
+		}
+
+		sum, err := checksum(source, filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			problems = append(problems, filename+" is missing")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if sum != recorded {
+			problems = append(problems, filename+" was modified after it was applied")
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating checksums: %w", err)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(problems, "; "))
+	}
+	return nil
+}
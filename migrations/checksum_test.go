@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// writeMigration writes a migration file into dir
+func writeMigration(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write migration %s: %v", name, err)
+	}
+}
+
+func TestVerifyDetectsModifiedMigration(t *testing.T) {
+	dir := t.TempDir()
+	writeMigration(t, dir, "1_users.up.sql", "CREATE TABLE users (id INTEGER PRIMARY KEY);")
+	writeMigration(t, dir, "1_users.down.sql", "DROP TABLE users;")
+	source := os.DirFS(dir)
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "verify.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Apply the migration
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	src, err := iofs.New(source, ".")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		t.Fatalf("Failed to create migrate instance: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("Failed to apply migration: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := RecordChecksums(ctx, db, source); err != nil {
+		t.Fatalf("Failed to record checksums: %v", err)
+	}
+	if err := Verify(ctx, db, source); err != nil {
+		t.Fatalf("Expected unchanged migrations to verify, got %v", err)
+	}
+
+	// Edit the migration after it was applied
+	writeMigration(t, dir, "1_users.up.sql", "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);")
+
+	err = Verify(ctx, db, source)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	// Recording again must not accept the edited file
+	if err := RecordChecksums(ctx, db, source); err != nil {
+		t.Fatalf("Failed to record checksums: %v", err)
+	}
+	if err := Verify(ctx, db, source); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected checksum to be kept after recording again, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LockTable holds the advisory lock taken while migrating
+const LockTable = "migration_lock"
+
+// LockTimeout is how long a lock may go without a heartbeat before another
+// instance may take it over, so a holder that crashed does not block
+// migrations forever. Holders renew the lock at a third of this interval
+// for as long as they run.
+const LockTimeout = time.Minute
+
+// lockPollInterval is how often Lock retries while another holder has it
+const lockPollInterval = 50 * time.Millisecond
+
+// ensureLockTable creates the lock table if it does not exist. It holds at
+// most one row, present while the lock is held.
+func ensureLockTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+LockTable+` (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		holder TEXT NOT NULL,
+		acquired_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating lock table: %w", err)
+	}
+	return nil
+}
+
+// Lock takes the advisory migration lock, waiting until it is free or ctx
+// is done, and returns a function that releases it. The lock lives in the
+// database, so it serializes migrations across every process sharing it,
+// such as instances of a service deployed against one Turso database. Up,
+// Down, Redo, Force and ApplyPending take it themselves.
+func Lock(ctx context.Context, db *sql.DB) (release func() error, err error) {
+	return lock(ctx, db, LockTimeout)
+}
+
+// lock takes the migration lock, treating a holder silent for longer than
+// timeout as crashed
+func lock(ctx context.Context, db *sql.DB, timeout time.Duration) (func() error, error) {
+	if err := ensureLockTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("generating lock holder: %w", err)
+	}
+	holder := hex.EncodeToString(id)
+
+	// Compare and swap: insert the row if the lock is free, or take it
+	// over if the current holder's heartbeat has expired
+	for {
+		now := time.Now()
+		res, err := db.ExecContext(ctx, `INSERT INTO `+LockTable+` (id, holder, acquired_at) VALUES (1, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET holder = excluded.holder, acquired_at = excluded.acquired_at
+			WHERE acquired_at < ?`,
+			holder, now.UnixMilli(), now.Add(-timeout).UnixMilli())
+		if err != nil {
+			return nil, fmt.Errorf("acquiring migration lock: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("acquiring migration lock: %w", err)
+		} else if n == 1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("acquiring migration lock: %w", ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	// Renew the lock until it is released
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(timeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				db.ExecContext(context.Background(),
+					"UPDATE "+LockTable+" SET acquired_at = ? WHERE id = 1 AND holder = ?",
+					time.Now().UnixMilli(), holder)
+			}
+		}
+	}()
+
+	var once sync.Once
+	release := func() error {
+		var err error
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+
+			var res sql.Result
+			res, err = db.ExecContext(context.Background(), "DELETE FROM "+LockTable+" WHERE id = 1 AND holder = ?", holder)
+			if err != nil {
+				err = fmt.Errorf("releasing migration lock: %w", err)
+				return
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				err = errLockLost
+			}
+		})
+		return err
+	}
+	return release, nil
+}
+
+// errLockLost is returned when releasing a lock that another instance took
+// over after its heartbeat expired
+var errLockLost = errors.New("releasing migration lock: lock was taken over by another holder")
@@ -0,0 +1,118 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// openSharedDB opens a file database the way a separate instance would
+func openSharedDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLockSerializesInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.db")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// A slow migration widens the window in which instances overlap
+	source := fstest.MapFS{
+		"1_runs.up.sql": {Data: []byte(`CREATE TABLE runs (version INTEGER PRIMARY KEY);
+			WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 200000)
+			INSERT INTO runs SELECT 1 WHERE (SELECT COUNT(*) FROM c) > 0;`)},
+		"1_runs.down.sql": {Data: []byte("DROP TABLE runs;")},
+		"2_more.up.sql":   {Data: []byte("INSERT INTO runs VALUES (2);")},
+		"2_more.down.sql": {Data: []byte("DELETE FROM runs WHERE version = 2;")},
+	}
+
+	const instances = 2
+	applied := make([]int, instances)
+	errs := make([]error, instances)
+
+	var wg sync.WaitGroup
+	for i := 0; i < instances; i++ {
+		db := openSharedDB(t, path)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			applied[i], errs[i] = ApplyPending(ctx, db, source)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Instance %d failed to migrate: %v", i, err)
+		}
+	}
+	if applied[0]+applied[1] != 2 || (applied[0] != 0 && applied[1] != 0) {
+		t.Errorf("Expected one instance to apply both migrations, got %v", applied)
+	}
+
+	db := openSharedDB(t, path)
+	var runs int
+	if err := db.QueryRow("SELECT COUNT(*) FROM runs").Scan(&runs); err != nil {
+		t.Fatalf("Failed to count runs: %v", err)
+	}
+	if runs != 2 {
+		t.Errorf("Expected each migration to run once, got %d runs", runs)
+	}
+
+	// The lock is free again
+	var held int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + LockTable).Scan(&held); err != nil {
+		t.Fatalf("Failed to read lock table: %v", err)
+	}
+	if held != 0 {
+		t.Error("Expected the lock to be released")
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	db := openSharedDB(t, filepath.Join(t.TempDir(), "lock.db"))
+	ctx := context.Background()
+
+	release, err := lock(ctx, db, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to take lock: %v", err)
+	}
+
+	// A live holder blocks others until their context ends
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if _, err := lock(waitCtx, db, time.Hour); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected to wait for the held lock, got %v", err)
+	}
+
+	if err := release(); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+
+	// A holder that crashed stops renewing, so its lock expires
+	_, err = db.Exec("INSERT INTO "+LockTable+" (id, holder, acquired_at) VALUES (1, 'crashed', ?)",
+		time.Now().Add(-time.Minute).UnixMilli())
+	if err != nil {
+		t.Fatalf("Failed to simulate crashed holder: %v", err)
+	}
+
+	release, err = lock(ctx, db, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to take over expired lock: %v", err)
+	}
+	if err := release(); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// ErrNoVersion is returned by Version when no migration has been applied
+var ErrNoVersion = migrate.ErrNilVersion
+
+// ErrDirtySchema is returned by CheckMigrationState when the last migration
+// failed part way, leaving the schema in an unknown state
+var ErrDirtySchema = errors.New("schema is dirty")
+
+// newDriver wraps db in a migrate database driver
+func newDriver(db *sql.DB) (database.Driver, error) {
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("creating migration driver: %w", err)
+	}
+	return driver, nil
+}
+
+// newMigrate returns a migrate instance applying migrations from source to
+// db. The instance must not be closed, as that would close db.
+func newMigrate(db *sql.DB, source fs.FS) (*migrate.Migrate, error) {
+	driver, err := newDriver(db)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := iofs.New(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migration source: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", &txDriver{Driver: driver})
+	if err != nil {
+		return nil, fmt.Errorf("creating migration instance: %w", err)
+	}
+	return m, nil
+}
+
+// run holds the migration lock while applying fn with runLocked
+func run(ctx context.Context, db *sql.DB, source fs.FS, fn func(*migrate.Migrate) error) (version uint, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	release, err := Lock(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Join(err, release())
+	}()
+
+	return runLocked(ctx, db, source, fn)
+}
+
+// runLocked applies fn, stopping between migrations once ctx is done,
+// records the checksums of the applied migrations and returns the resulting
+// version
+func runLocked(ctx context.Context, db *sql.DB, source fs.FS, fn func(*migrate.Migrate) error) (uint, error) {
+	m, err := newMigrate(db, source)
+	if err != nil {
+		return 0, err
+	}
+
+	// Ask migrate to stop after the current migration if ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { m.GracefulStop <- true })
+	defer stop()
+
+	if err := fn(m); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, fmt.Errorf("running migrations: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if err := RecordChecksums(ctx, db, source); err != nil {
+		return 0, err
+	}
+
+	version, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading migration version: %w", err)
+	}
+	return version, nil
+}
+
+// Up applies all pending migrations from source and returns the new version.
+// It is not an error if there is nothing to apply.
+func Up(ctx context.Context, db *sql.DB, source fs.FS) (uint, error) {
+	return run(ctx, db, source, func(m *migrate.Migrate) error {
+		return m.Up()
+	})
+}
+
+// ApplyPending applies the up migrations in source newer than the current
+// version and returns how many were applied. The count is taken under the
+// same lock as the migration, so when several instances call it at once one
+// applies the migrations and the others report none.
+func ApplyPending(ctx context.Context, db *sql.DB, source fs.FS) (applied int, err error) {
+	release, err := Lock(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Join(err, release())
+	}()
+
+	pending, err := Pending(ctx, db, source)
+	if err != nil {
+		return 0, err
+	}
+	if pending == 0 {
+		return 0, nil
+	}
+
+	_, err = runLocked(ctx, db, source, func(m *migrate.Migrate) error {
+		return m.Up()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return pending, nil
+}
+
+// Down rolls back all applied migrations and returns the resulting version,
+// which is 0 once every migration has been reverted
+func Down(ctx context.Context, db *sql.DB, source fs.FS) (uint, error) {
+	return run(ctx, db, source, func(m *migrate.Migrate) error {
+		return m.Down()
+	})
+}
+
+// Redo rolls back the most recent migration and applies it again, returning
+// the resulting version. It returns ErrNoVersion if nothing has been applied.
+func Redo(ctx context.Context, db *sql.DB, source fs.FS) (uint, error) {
+	if _, _, err := Version(ctx, db); err != nil {
+		return 0, err
+	}
+
+	return run(ctx, db, source, func(m *migrate.Migrate) error {
+		if err := m.Steps(-1); err != nil {
+			return err
+		}
+
+		// Forget the old checksum so edits to the redone migration are accepted
+		if err := RecordChecksums(ctx, db, source); err != nil {
+			return err
+		}
+		return m.Steps(1)
+	})
+}
+
+// Version returns the current migration version and whether it is dirty
+func Version(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	driver, err := newDriver(db)
+	if err != nil {
+		return 0, false, err
+	}
+
+	version, dirty, err := driver.Version()
+	if err != nil {
+		return 0, false, fmt.Errorf("reading migration version: %w", err)
+	}
+	if version == database.NilVersion {
+		return 0, false, ErrNoVersion
+	}
+	return uint(version), dirty, nil
+}
+
+// Pending returns the number of up migrations in source newer than the
+// current version of db
+func Pending(ctx context.Context, db *sql.DB, source fs.FS) (int, error) {
+	current, _, err := Version(ctx, db)
+	none := errors.Is(err, ErrNoVersion)
+	if err != nil && !none {
+		return 0, err
+	}
+
+	files, err := upFiles(source)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, f := range files {
+		if none || f.Version > uint64(current) {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// CheckMigrationState returns the current migration version and fails with
+// ErrDirtySchema if it is dirty, so services can refuse to start on a
+// half-migrated database. A database with no migrations applied is reported
+// as version 0 and not dirty.
+func CheckMigrationState(ctx context.Context, db *sql.DB) (version uint, dirty bool, err error) {
+	version, dirty, err = Version(ctx, db)
+	if errors.Is(err, ErrNoVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if dirty {
+		return version, true, fmt.Errorf("migration version %d: %w", version, ErrDirtySchema)
+	}
+	return version, false, nil
+}
+
+// Force sets the migration version without running any migrations and
+// clears the dirty flag, for recovering from a failed migration
+func Force(ctx context.Context, db *sql.DB, version int) (err error) {
+	release, err := Lock(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, release())
+	}()
+
+	driver, err := newDriver(db)
+	if err != nil {
+		return err
+	}
+
+	if err := driver.SetVersion(version, false); err != nil {
+		return fmt.Errorf("forcing version %d: %w", version, err)
+	}
+	return nil
+}
+
+// Drop removes every view and table, including the migration version
+// table, and returns the number dropped. The migrate sqlite driver's Drop
+// keeps its table listing open while dropping, which deadlocks on a single
+// connection, so the schema is listed before anything is dropped. Views go
+// first, then virtual tables, whose shadow tables such as an FTS5 table's
+// _data are dropped with them and skipped, then ordinary tables.
+func Drop(ctx context.Context, db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT name, type FROM pragma_table_list
+		WHERE schema = 'main' AND type IN ('view', 'virtual', 'table') AND name NOT LIKE 'sqlite_%'
+		ORDER BY CASE type WHEN 'view' THEN 0 WHEN 'virtual' THEN 1 ELSE 2 END, name`)
+	if err != nil {
+		return 0, fmt.Errorf("listing tables: %w", err)
+	}
+
+	type object struct{ name, kind string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.name, &o.kind); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning table name: %w", err)
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("listing tables: %w", err)
+	}
+
+	for _, o := range objects {
+		quoted := `"` + strings.ReplaceAll(o.name, `"`, `""`) + `"`
+		stmt := "DROP TABLE IF EXISTS " + quoted
+		if o.kind == "view" {
+			stmt = "DROP VIEW IF EXISTS " + quoted
+		}
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return 0, fmt.Errorf("dropping %s %s: %w", o.kind, o.name, err)
+		}
+	}
+
+	// Reclaim the space used by the dropped tables
+	if len(objects) > 0 {
+		if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
+			return 0, fmt.Errorf("vacuuming: %w", err)
+		}
+	}
+
+	return len(objects), nil
+}
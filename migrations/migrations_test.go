@@ -0,0 +1,289 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+// testSource holds two migrations creating users and emails
+var testSource = fstest.MapFS{
+	"1_users.up.sql":    {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);")},
+	"1_users.down.sql":  {Data: []byte("DROP TABLE users;")},
+	"2_emails.up.sql":   {Data: []byte("CREATE TABLE emails (id INTEGER PRIMARY KEY, user_id INTEGER);")},
+	"2_emails.down.sql": {Data: []byte("DROP TABLE emails;")},
+}
+
+// openTestDB opens an in-memory database on a single connection so every
+// statement sees the same database
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// tableExists reports whether a table exists in db
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query schema: %v", err)
+	}
+	return count > 0
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	if _, _, err := Version(ctx, db); !errors.Is(err, ErrNoVersion) {
+		t.Fatalf("Expected ErrNoVersion before migrating, got %v", err)
+	}
+
+	version, err := Up(ctx, db, testSource)
+	if err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+	if version != 2 {
+		t.Errorf("Expected version 2, got %d", version)
+	}
+	if !tableExists(t, db, "users") || !tableExists(t, db, "emails") {
+		t.Error("Expected users and emails tables to exist")
+	}
+
+	// Running again is a no-op
+	version, err = Up(ctx, db, testSource)
+	if err != nil {
+		t.Fatalf("Failed to re-run up: %v", err)
+	}
+	if version != 2 {
+		t.Errorf("Expected version 2 after no-op, got %d", version)
+	}
+
+	current, dirty, err := Version(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if current != 2 || dirty {
+		t.Errorf("Expected clean version 2, got %d (dirty: %v)", current, dirty)
+	}
+
+	version, err = Down(ctx, db, testSource)
+	if err != nil {
+		t.Fatalf("Failed to migrate down: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("Expected version 0 after down, got %d", version)
+	}
+	if tableExists(t, db, "users") || tableExists(t, db, "emails") {
+		t.Error("Expected tables to be dropped")
+	}
+}
+
+func TestRedo(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	if _, err := Redo(ctx, db, testSource); !errors.Is(err, ErrNoVersion) {
+		t.Fatalf("Expected ErrNoVersion with nothing applied, got %v", err)
+	}
+
+	if _, err := Up(ctx, db, testSource); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO emails (user_id) VALUES (1)"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	// Edit the latest migration, as when iterating on it
+	source := fstest.MapFS{}
+	for name, file := range testSource {
+		source[name] = file
+	}
+	source["2_emails.up.sql"] = &fstest.MapFile{
+		Data: []byte("CREATE TABLE emails (id INTEGER PRIMARY KEY, user_id INTEGER, subject TEXT);"),
+	}
+
+	version, err := Redo(ctx, db, source)
+	if err != nil {
+		t.Fatalf("Failed to redo: %v", err)
+	}
+	if version != 2 {
+		t.Errorf("Expected version 2 after redo, got %d", version)
+	}
+
+	// The table was recreated from the edited file
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM emails WHERE subject IS NULL").Scan(&count); err != nil {
+		t.Fatalf("Failed to query redone table: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected recreated table to be empty, got %d rows", count)
+	}
+
+	if err := Verify(ctx, db, source); err != nil {
+		t.Errorf("Expected redone migration to verify, got %v", err)
+	}
+}
+
+func TestForce(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	// A failing migration leaves the version dirty
+	source := fstest.MapFS{
+		"1_broken.up.sql":   {Data: []byte("CREATE TABLE broken (;")},
+		"1_broken.down.sql": {Data: []byte("DROP TABLE IF EXISTS broken;")},
+	}
+	if _, err := Up(ctx, db, source); err == nil {
+		t.Fatal("Expected broken migration to fail")
+	}
+
+	_, dirty, err := Version(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if !dirty {
+		t.Fatal("Expected dirty version after failed migration")
+	}
+
+	if err := Force(ctx, db, 1); err != nil {
+		t.Fatalf("Failed to force version: %v", err)
+	}
+
+	version, dirty, err := Version(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if version != 1 || dirty {
+		t.Errorf("Expected clean version 1, got %d (dirty: %v)", version, dirty)
+	}
+}
+
+func TestUpCancelled(t *testing.T) {
+	db := openTestDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Up(ctx, db, testSource); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	if _, err := Up(ctx, db, testSource); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	// users, emails, schema_migrations, the checksum table and the lock table
+	dropped, err := Drop(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to drop: %v", err)
+	}
+	if dropped != 5 {
+		t.Errorf("Expected 5 tables dropped, got %d", dropped)
+	}
+	if tableExists(t, db, "users") || tableExists(t, db, "schema_migrations") {
+		t.Error("Expected all tables to be dropped")
+	}
+}
+
+func TestDropVirtualTablesAndViews(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		"CREATE TABLE emails (id INTEGER PRIMARY KEY, subject TEXT)",
+		"CREATE VIRTUAL TABLE emails_fts USING fts5(subject)",
+		"CREATE VIEW recent_emails AS SELECT id FROM emails",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+
+	// The view, the FTS5 table and emails; the shadow tables go with emails_fts
+	dropped, err := Drop(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to drop: %v", err)
+	}
+	if dropped != 3 {
+		t.Errorf("Expected 3 objects dropped, got %d", dropped)
+	}
+
+	var remaining int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master").Scan(&remaining); err != nil {
+		t.Fatalf("Failed to count schema objects: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("Expected an empty schema, got %d objects", remaining)
+	}
+}
+
+func TestCheckMigrationState(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	// A fresh database is clean
+	version, dirty, err := CheckMigrationState(ctx, db)
+	if err != nil || version != 0 || dirty {
+		t.Fatalf("Expected clean version 0, got %d (dirty: %v, err: %v)", version, dirty, err)
+	}
+
+	if _, err := Up(ctx, db, testSource); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+	version, dirty, err = CheckMigrationState(ctx, db)
+	if err != nil || version != 2 || dirty {
+		t.Fatalf("Expected clean version 2, got %d (dirty: %v, err: %v)", version, dirty, err)
+	}
+
+	// Force a dirty state as a failed migration would leave it
+	driver, err := newDriver(db)
+	if err != nil {
+		t.Fatalf("Failed to create driver: %v", err)
+	}
+	if err := driver.SetVersion(3, true); err != nil {
+		t.Fatalf("Failed to set dirty version: %v", err)
+	}
+
+	version, dirty, err = CheckMigrationState(ctx, db)
+	if !errors.Is(err, ErrDirtySchema) {
+		t.Fatalf("Expected ErrDirtySchema, got %v", err)
+	}
+	if version != 3 || !dirty {
+		t.Errorf("Expected dirty version 3, got %d (dirty: %v)", version, dirty)
+	}
+}
+
+func TestPending(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	n, err := Pending(ctx, db, testSource)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 pending migrations, got %d (%v)", n, err)
+	}
+
+	if err := Force(ctx, db, 1); err != nil {
+		t.Fatalf("Failed to force version: %v", err)
+	}
+	n, err = Pending(ctx, db, testSource)
+	if err != nil || n != 1 {
+		t.Errorf("Expected 1 pending migration, got %d (%v)", n, err)
+	}
+}
@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// schemaObject is a row of sqlite_master
+type schemaObject struct {
+	typ   string
+	name  string
+	table string // Table or view an index or trigger belongs to
+	sql   string
+}
+
+// DumpSchema writes the CREATE statements of every table, index, trigger and
+// view in db to w, so that the effective schema can be reviewed against the
+// migration files. The order is deterministic: each table sorted by name is
+// followed by its indexes and then its triggers, and views follow the
+// tables, each with its triggers. Internal SQLite objects, automatic indexes
+// and the shadow tables of virtual tables are left out, as they are created
+// implicitly.
+func DumpSchema(ctx context.Context, db *sql.DB, w io.Writer) error {
+	objects, err := schemaObjects(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// Group indexes and triggers under the table or view they belong to
+	byTable := map[string][]schemaObject{}
+	var owners []schemaObject
+	for _, o := range objects {
+		switch o.typ {
+		case "table", "view":
+			owners = append(owners, o)
+		default:
+			byTable[o.table] = append(byTable[o.table], o)
+		}
+	}
+
+	rank := map[string]int{"table": 0, "view": 1, "index": 0, "trigger": 1}
+	sort.SliceStable(owners, func(i, j int) bool {
+		if owners[i].typ != owners[j].typ {
+			return rank[owners[i].typ] < rank[owners[j].typ]
+		}
+		return owners[i].name < owners[j].name
+	})
+
+	for _, owner := range owners {
+		children := byTable[owner.name]
+		sort.SliceStable(children, func(i, j int) bool {
+			if children[i].typ != children[j].typ {
+				return rank[children[i].typ] < rank[children[j].typ]
+			}
+			return children[i].name < children[j].name
+		})
+
+		for _, o := range append([]schemaObject{owner}, children...) {
+			if _, err := fmt.Fprintf(w, "%s;\n\n", strings.TrimSpace(o.sql)); err != nil {
+				return fmt.Errorf("writing schema: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// schemaObjects lists the user-defined objects of db
+func schemaObjects(ctx context.Context, db *sql.DB) ([]schemaObject, error) {
+	shadow := map[string]bool{}
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_list WHERE schema = 'main' AND type = 'shadow'")
+	if err != nil {
+		return nil, fmt.Errorf("listing shadow tables: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning shadow table: %w", err)
+		}
+		shadow[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing shadow tables: %w", err)
+	}
+
+	rows, err = db.QueryContext(ctx, `SELECT type, name, tbl_name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []schemaObject
+	for rows.Next() {
+		var o schemaObject
+		if err := rows.Scan(&o.typ, &o.name, &o.table, &o.sql); err != nil {
+			return nil, fmt.Errorf("scanning schema: %w", err)
+		}
+		if shadow[o.name] {
+			continue
+		}
+		objects = append(objects, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	return objects, nil
+}
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDumpSchema(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	source := fstest.MapFS{
+		"1_schema.up.sql": {Data: []byte(`
+			CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT UNIQUE);
+			CREATE VIEW active_users AS SELECT id FROM users;
+			CREATE TABLE emails (id INTEGER PRIMARY KEY, user_id INTEGER, subject TEXT);
+			CREATE INDEX idx_emails_user ON emails (user_id);
+			CREATE TRIGGER emails_touch AFTER UPDATE ON emails BEGIN SELECT 1; END;
+			CREATE VIRTUAL TABLE emails_fts USING fts5(subject);`)},
+		"1_schema.down.sql": {Data: []byte("DROP TABLE emails; DROP VIEW active_users; DROP TABLE users;")},
+	}
+	if _, err := Up(ctx, db, source); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := DumpSchema(ctx, db, &out); err != nil {
+		t.Fatalf("Failed to dump schema: %v", err)
+	}
+	dump := out.String()
+
+	// Tables by name, each followed by its indexes and triggers, then views
+	expected := []string{
+		"CREATE TABLE emails ",
+		"CREATE INDEX idx_emails_user ",
+		"CREATE TRIGGER emails_touch ",
+		"CREATE VIRTUAL TABLE emails_fts ",
+		"CREATE TABLE users ",
+		"CREATE VIEW active_users ",
+	}
+	last := -1
+	for _, stmt := range expected {
+		i := strings.Index(dump, stmt)
+		if i < 0 {
+			t.Fatalf("Expected %q in dump:\n%s", stmt, dump)
+		}
+		if i < last {
+			t.Errorf("Expected %q after the previous statement in dump:\n%s", stmt, dump)
+		}
+		last = i
+	}
+
+	// Implicit objects are left out
+	for _, name := range []string{"sqlite_autoindex", "emails_fts_data", "emails_fts_config"} {
+		if strings.Contains(dump, name) {
+			t.Errorf("Expected %s to be left out of dump:\n%s", name, dump)
+		}
+	}
+
+	// The dump recreates the same schema
+	replay := openTestDB(t)
+	if _, err := replay.ExecContext(ctx, dump); err != nil {
+		t.Fatalf("Failed to replay dump: %v", err)
+	}
+	var again bytes.Buffer
+	if err := DumpSchema(ctx, replay, &again); err != nil {
+		t.Fatalf("Failed to dump replayed schema: %v", err)
+	}
+	if again.String() != dump {
+		t.Errorf("Expected replayed schema to match, got:\n%s", again.String())
+	}
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Seed executes every .sql file in source in filename order and returns the
+// number of files run. Seeds are not tracked and may run any number of
+// times, so they should use INSERT OR IGNORE or ON CONFLICT clauses. Each
+// file runs in its own transaction.
+func Seed(ctx context.Context, db *sql.DB, source fs.FS) (int, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return 0, fmt.Errorf("reading seeds: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+
+	for i, name := range files {
+		data, err := fs.ReadFile(source, name)
+		if err != nil {
+			return i, fmt.Errorf("reading seed %s: %w", name, err)
+		}
+		if err := execSeed(ctx, db, string(data)); err != nil {
+			return i, fmt.Errorf("running seed %s: %w", name, err)
+		}
+	}
+
+	return len(files), nil
+}
+
+// execSeed runs a seed file inside a transaction
+func execSeed(ctx context.Context, db *sql.DB, query string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
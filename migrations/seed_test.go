@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSeed(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	if _, err := db.Exec("CREATE TABLE labels (id INTEGER PRIMARY KEY, name TEXT UNIQUE NOT NULL)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	source := fstest.MapFS{
+		"01_labels.sql": {Data: []byte(`
+			INSERT OR IGNORE INTO labels (id, name) VALUES (1, 'inbox');
+			INSERT OR IGNORE INTO labels (id, name) VALUES (2, 'archive');`)},
+		"02_more_labels.sql": {Data: []byte(`
+			INSERT INTO labels (id, name) VALUES (3, 'spam')
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name;`)},
+		"notes.txt": {Data: []byte("not a seed")},
+	}
+
+	// Seeding twice must leave the same data
+	for i := 0; i < 2; i++ {
+		n, err := Seed(ctx, db, source)
+		if err != nil {
+			t.Fatalf("Failed to seed (run %d): %v", i+1, err)
+		}
+		if n != 2 {
+			t.Errorf("Expected 2 seed files run, got %d", n)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM labels").Scan(&count); err != nil {
+		t.Fatalf("Failed to count labels: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 labels, got %d", count)
+	}
+}
@@ -0,0 +1 @@
+CREATE TABLE emails (id INTEGER PRIMARY KEY);
@@ -0,0 +1 @@
+-- Migration Up
@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// transactionDirective marks a migration whose failure should leave the
+// version exactly as it was
+const transactionDirective = "-- transaction: true"
+
+// txDriver wraps a migrate driver to support the transaction directive. The
+// sqlite driver already runs each file in a transaction, so a failed file
+// leaves no partial effects, but migrate still marks the version dirty. For
+// files carrying the directive the previous clean version is restored
+// instead, so no Force is needed before fixing and retrying the migration.
+type txDriver struct {
+	database.Driver
+
+	prev       int  // Version before the migration being run
+	restorable bool // Whether prev was clean and can be restored
+}
+
+// SetVersion remembers the clean version in place before a migration starts
+func (d *txDriver) SetVersion(version int, dirty bool) error {
+	if dirty {
+		prev, prevDirty, err := d.Driver.Version()
+		if err != nil {
+			return err
+		}
+		d.prev, d.restorable = prev, !prevDirty
+	}
+	return d.Driver.SetVersion(version, dirty)
+}
+
+// Run applies a migration, restoring the previous version if a file marked
+// with the transaction directive fails
+func (d *txDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	err = d.Driver.Run(bytes.NewReader(body))
+	if err == nil || !d.restorable || !isTransactional(body) {
+		return err
+	}
+
+	if verr := d.Driver.SetVersion(d.prev, false); verr != nil {
+		return errors.Join(err, fmt.Errorf("restoring version %d: %w", d.prev, verr))
+	}
+	return err
+}
+
+// isTransactional reports whether the leading comments of a migration
+// contain the transaction directive
+func isTransactional(body []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			return false
+		}
+		if strings.EqualFold(strings.Join(strings.Fields(line), " "), transactionDirective) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTransactionalMigration(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	// The second statement of migration 2 fails
+	source := fstest.MapFS{
+		"1_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);")},
+		"1_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"2_emails.up.sql": {Data: []byte(`-- transaction: true
+CREATE TABLE emails (id INTEGER PRIMARY KEY);
+INSERT INTO missing_table VALUES (1);`)},
+		"2_emails.down.sql": {Data: []byte("DROP TABLE emails;")},
+	}
+
+	if _, err := Up(ctx, db, source); err == nil {
+		t.Fatal("Expected migration 2 to fail")
+	}
+
+	// No partial effects remain and the version is still clean
+	if tableExists(t, db, "emails") {
+		t.Error("Expected emails table to be rolled back")
+	}
+	version, dirty, err := Version(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if version != 1 || dirty {
+		t.Errorf("Expected clean version 1, got %d (dirty: %v)", version, dirty)
+	}
+
+	// Fixing the file lets it apply without forcing the version
+	source["2_emails.up.sql"] = &fstest.MapFile{Data: []byte(`-- transaction: true
+CREATE TABLE emails (id INTEGER PRIMARY KEY);`)}
+	version, err = Up(ctx, db, source)
+	if err != nil {
+		t.Fatalf("Failed to apply fixed migration: %v", err)
+	}
+	if version != 2 {
+		t.Errorf("Expected version 2, got %d", version)
+	}
+}
+
+func TestIsTransactional(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{"-- transaction: true\nCREATE TABLE a (id INTEGER);", true},
+		{"-- Add users\n--   Transaction:  TRUE\nCREATE TABLE a (id INTEGER);", true},
+		{"CREATE TABLE a (id INTEGER);\n-- transaction: true", false},
+		{"-- transaction: false\nCREATE TABLE a (id INTEGER);", false},
+	}
+	for _, tt := range tests {
+		if got := isTransactional([]byte(tt.body)); got != tt.want {
+			t.Errorf("isTransactional(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
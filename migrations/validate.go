@@ -0,0 +1,128 @@
+package migrations
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// placeholders are the bodies written by the CLI's new command
+var placeholders = map[string]bool{
+	"-- Migration Up":   true,
+	"-- Migration Down": true,
+}
+
+// Issue describes a problem with a migration file
+type Issue struct {
+	File    string
+	Problem string
+}
+
+// String formats the issue for display
+func (i Issue) String() string {
+	return i.File + ": " + i.Problem
+}
+
+// Validate checks the migrations in source for common mistakes without
+// touching a database: unparseable filenames, up files without a matching
+// down file and vice versa, duplicate versions, versions that do not
+// increase in filename order, and files still holding only the placeholder.
+func Validate(source fs.FS) ([]Issue, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	var issues []Issue
+	var files []migrationFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		f, ok := parseFilename(entry.Name())
+		if !ok {
+			issues = append(issues, Issue{entry.Name(), "filename does not match <version>_<name>.(up|down).sql"})
+			continue
+		}
+		files = append(files, f)
+
+		data, err := fs.ReadFile(source, f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", f.Path, err)
+		}
+		body := strings.TrimSpace(string(data))
+		if body == "" || placeholders[body] {
+			issues = append(issues, Issue{f.Path, "contains no statements"})
+		}
+	}
+
+	// Group files by version to find missing pairs and duplicates
+	byVersion := map[uint64][]migrationFile{}
+	for _, f := range files {
+		byVersion[f.Version] = append(byVersion[f.Version], f)
+	}
+
+	versions := make([]uint64, 0, len(byVersion))
+	for v := range byVersion {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, v := range versions {
+		group := byVersion[v]
+
+		names := map[string]bool{}
+		directions := map[string]string{}
+		for _, f := range group {
+			names[f.Name] = true
+			directions[f.Direction] = f.Path
+		}
+		if len(names) > 1 {
+			paths := make([]string, 0, len(group))
+			for _, f := range group {
+				paths = append(paths, f.Path)
+			}
+			sort.Strings(paths)
+			issues = append(issues, Issue{paths[0], fmt.Sprintf("version %d is used by %s", v, strings.Join(paths, ", "))})
+			continue
+		}
+
+		if up, ok := directions["up"]; ok && directions["down"] == "" {
+			issues = append(issues, Issue{up, "missing matching down migration"})
+		}
+		if down, ok := directions["down"]; ok && directions["up"] == "" {
+			issues = append(issues, Issue{down, "missing matching up migration"})
+		}
+	}
+
+	// Versions should increase in the order the files are listed
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	for i := 1; i < len(files); i++ {
+		prev, f := files[i-1], files[i]
+		if f.Version < prev.Version {
+			issues = append(issues, Issue{f.Path, fmt.Sprintf("sorts after %s but has a lower version", prev.Path)})
+		}
+	}
+
+	return issues, nil
+}
+
+// NextSequence returns the version for a new sequentially numbered migration
+// in source: one more than the highest existing version. Timestamped files
+// are included so the new migration still sorts and applies after them.
+func NextSequence(source fs.FS) (uint64, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return 0, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	var highest uint64
+	for _, entry := range entries {
+		if f, ok := parseFilename(entry.Name()); ok && f.Version > highest {
+			highest = f.Version
+		}
+	}
+	return highest + 1, nil
+}
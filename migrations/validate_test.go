@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		dir     string
+		file    string
+		problem string
+	}{
+		{"bad_name", "add_users.sql", "filename does not match"},
+		{"missing_down", "1_users.up.sql", "missing matching down"},
+		{"duplicate", "1_emails.down.sql", "version 1 is used by"},
+		{"unordered", "9_users.down.sql", "lower version"},
+		{"placeholder", "1_users.up.sql", "no statements"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			issues, err := Validate(os.DirFS(filepath.Join("testdata", tt.dir)))
+			if err != nil {
+				t.Fatalf("Failed to validate: %v", err)
+			}
+			if len(issues) != 1 {
+				t.Fatalf("Expected 1 issue, got %v", issues)
+			}
+			if issues[0].File != tt.file || !strings.Contains(issues[0].Problem, tt.problem) {
+				t.Errorf("Expected %s: %s, got %s", tt.file, tt.problem, issues[0])
+			}
+		})
+	}
+}
+
+func TestValidateClean(t *testing.T) {
+	issues, err := Validate(os.DirFS(filepath.Join("testdata", "valid")))
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestNextSequence(t *testing.T) {
+	dir := t.TempDir()
+
+	// Empty directories start at 1
+	next, err := NextSequence(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("Failed to compute next sequence: %v", err)
+	}
+	if next != 1 {
+		t.Errorf("Expected 1, got %d", next)
+	}
+
+	for _, name := range []string{"0001_users.up.sql", "0001_users.down.sql", "0002_emails.up.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	next, err = NextSequence(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("Failed to compute next sequence: %v", err)
+	}
+	if next != 3 {
+		t.Errorf("Expected 3, got %d", next)
+	}
+
+	// A timestamped migration must still be applied before the new one
+	if err := os.WriteFile(filepath.Join(dir, "1746507520_labels.up.sql"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+	next, err = NextSequence(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("Failed to compute next sequence: %v", err)
+	}
+	if next != 1746507521 {
+		t.Errorf("Expected 1746507521, got %d", next)
+	}
+}